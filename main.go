@@ -1,46 +1,485 @@
 package main
 
 import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
 	"github.com/emicklei/dot"
+	"github.com/maxvdkolk/vimwikigraph/vimwiki"
 )
 
+// stringList collects the values of a flag that can be passed multiple times.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// oneOf returns true when value equals any of the options.
+func oneOf(value string, options ...string) bool {
+	for _, o := range options {
+		if value == o {
+			return true
+		}
+	}
+	return false
+}
+
+// isSet returns true when the flag of the given name is passed explicitly.
+func isSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// defaultCacheDir returns the vimwikigraph directory inside the user's cache
+// directory, or inside the temporary directory when there is none.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "vimwikigraph")
+}
+
+// readPaths returns the non-empty lines of r as paths.
+func readPaths(r io.Reader) []string {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path := strings.TrimSpace(scanner.Text()); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error when reading stdin: %v", err)
+	}
+	return paths
+}
+
+// readAliases returns the labels of nodes by their path from the JSON object
+// in the file at name.
+func readAliases(name string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]string)
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, err
+	}
+
+	// compare clean paths only, e.g. ./index.wiki equals index.wiki
+	clean := make(map[string]string, len(labels))
+	for p, label := range labels {
+		clean[path.Clean(filepath.ToSlash(p))] = label
+	}
+	return clean, nil
+}
+
+// readNodes returns the clean paths of the nodes listed one per line in the
+// file at name, skipping empty lines.
+func readNodes(name string) ([]string, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			nodes = append(nodes, path.Clean(filepath.ToSlash(line)))
+		}
+	}
+	return nodes, nil
+}
+
+// readTemplate returns the template for the dot output in the file at name.
+func readTemplate(name string) (*template.Template, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return vimwiki.NewDotTemplate(filepath.Base(name), string(data))
+}
+
+// nopCloser is a writer whose Close does nothing, e.g. to not close stdout.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// gzipFile compresses everything written to it into a file.
+type gzipFile struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (g gzipFile) Close() error {
+	if err := g.Writer.Close(); err != nil {
+		g.file.Close()
+		return err
+	}
+	return g.file.Close()
+}
+
+// createOutput returns a writer to the file at path, which is compressed if
+// path ends in .gz, or to stdout if path is empty.
+func createOutput(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(path, ".gz") {
+		return gzipFile{gzip.NewWriter(file), file}, nil
+	}
+	return file, nil
+}
+
+// extensions holds the file extension of each output format.
+var extensions = map[string]string{
+	"dot":      ".dot",
+	"gexf":     ".gexf",
+	"jgf":      ".json",
+	"json":     ".json",
+	"edgelist": ".tsv",
+}
+
+// outputPath returns the path of the file of format with basename base. A .gz
+// suffix of base is moved behind the extension of the format, such that the
+// file is still compressed, e.g. graph.gz -> graph.dot.gz.
+func outputPath(base, format string) string {
+	if strings.HasSuffix(base, ".gz") {
+		return strings.TrimSuffix(base, ".gz") + extensions[format] + ".gz"
+	}
+	return base + extensions[format]
+}
+
+// writeFile writes the output of emit to the file at path.
+func writeFile(path string, emit func(io.Writer) error) error {
+	w, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	if err := emit(w); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// printVersion writes the version of the module of the binary and of the
+// installed GraphViz dot, if any, to w.
+func printVersion(w io.Writer) {
+	version := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+	fmt.Fprintf(w, "vimwikigraph %s\n", version)
+
+	// dot writes its version to stderr
+	out, err := exec.Command("dot", "-V").CombinedOutput()
+	if err != nil {
+		fmt.Fprintln(w, "graphviz: not found")
+		return
+	}
+	fmt.Fprintf(w, "graphviz: %s\n", strings.TrimSpace(string(out)))
+}
+
 // example: go run main.go example | dot -Tpng > test.png && open test.png
 func main() {
 
-	// fall back to current directory if no directory given
+	// the directory precedes any flags
 	var dir string
-	if len(os.Args) == 1 {
-		dir, _ = os.Executable()
-		fmt.Fprintf(os.Stderr, "warning: using current directory: '%s'\n", dir)
-	} else {
-		if os.Args[1] != "-h" {
-			dir = os.Args[1]
-			os.Args = os.Args[1:]
-		}
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		dir = os.Args[1]
+		os.Args = os.Args[1:]
 	}
 
 	cluster := flag.Bool("cluster", false, "cluster nodes in sub directories")
+	components := flag.Bool("components", false, "cluster nodes by connected component, cannot be combined with -cluster")
 	diary := flag.Bool("diary", false, "collapse all diary entries under a single `diary.wiki` node")
-	level := flag.Int("l", 1, "draw only edges from nodes with at least level number of edges")
-	ignoreRegex := flag.String("ignore", "", "ignore any files that match the given regex")
+	diaryBy := flag.String("diary-by", "", "cluster diary entries by their week, month or year instead of collapsing them")
+	diaryDir := flag.String("diary-dir", "diary", "directory of the diary entries, relative to the wiki's directory")
+	level := flag.Int("l", 1, "draw only edges from nodes with at least level number of edges, see -degree-mode")
+	noMarkdown := flag.Bool("no-markdown", false, "skip links in markdown syntax")
+	noWiki := flag.Bool("no-wiki", false, "skip links in vimwiki syntax")
+	defaultExt := flag.String("default-ext", "", "extension appended to links without one, .wiki or .md, instead of .wiki for vimwiki and .md for markdown links")
+	frontMatterKey := flag.String("frontmatter-key", "", "key of a list of links in the YAML front matter of each file, e.g. links")
+	frontMatterOnly := flag.Bool("frontmatter-only", false, "only include the links listed in the front matter, see -frontmatter-key")
+	strict := flag.Bool("strict", false, "report links with invalid syntax, e.g. [[]], and exit with a non-zero status")
+	wikiRegex := flag.String("wiki-regex", "", "regex matching vimwiki syntax links, whose first group captures the link")
+	markdownRegex := flag.String("markdown-regex", "", "regex matching markdown syntax links, whose first two groups capture the description and target")
+	titles := flag.Bool("titles", false, "label nodes by the first heading of their file")
+	aliases := flag.String("aliases", "", "JSON file mapping the paths of nodes to their labels, e.g. {\"index.wiki\": \"Home\"}")
+	edgeLabels := flag.Bool("edge-labels", false, "label edges by the description of their link")
+	undirected := flag.Bool("undirected", false, "draw an undirected graph, merging links in both directions into a single edge")
+	mergeExtensions := flag.Bool("merge-extensions", false, "draw files with the same name but a .wiki or .md extension as a single node")
+	shapes := flag.Bool("shapes", false, "shape nodes by file type: boxes for .wiki, ellipses for .md, hexagons otherwise")
+	assets := flag.Bool("assets", false, "include links to files other than notes, e.g. images, as leaf nodes")
+	externals := flag.Bool("externals", false, "include links to external websites, grouped in a separate cluster")
+	noSelfLoops := flag.Bool("no-self-loops", true, "skip links from a file to itself")
+	urls := flag.Bool("urls", false, "link each node to its file, such that nodes can be clicked in SVG output")
+	urlBase := flag.String("url-base", "", "prefix of the link of each node, implies -urls, defaults to a file:// URL of the directory")
+	colorByAge := flag.Bool("color-by-age", false, "color edges by the modification time of their source file, from red (old) to green (recent)")
+	tasks := flag.Bool("tasks", false, "draw links in checkbox items, i.e. - [ ] task [[link]], as dashed edges")
+	tooltips := flag.Bool("tooltips", false, "add tooltips with the number of outgoing and incoming links to each node")
+	meta := flag.Bool("meta", false, "add tooltips with the number of words and the headings of its file to each node")
+	verbose := flag.Bool("v", false, "log every directory that is entered or skipped")
+	veryVerbose := flag.Bool("vv", false, "log every directory and every link and how it is renamed")
+	timing := flag.Bool("timing", false, "print the number of processed files, nodes and edges and the time taken to stderr when done")
+	progress := flag.Bool("progress", false, "periodically report the number of processed files to stderr")
+	followSymlinks := flag.Bool("follow-symlinks", false, "walk the directories that symbolic links refer to")
+	only := flag.String("only", "", "file listing the nodes to draw, one per line, together with the links between them")
+	tree := flag.String("tree", "", "draw the notes reachable from this node as a tree from top to bottom, e.g. index.wiki")
+	collapseToDirs := flag.Bool("collapse-to-dirs", false, "draw a node for each directory, with edges labelled by the number of links between them")
+	crossDirOnly := flag.Bool("cross-dir-only", false, "keep only links between files in different top-level directories")
+	followRedirects := flag.Bool("follow-redirects", false, "drop notes consisting of a single link and redirect the links to them to its target")
+	includeHidden := flag.Bool("include-hidden", false, "walk files and directories whose name starts with a dot")
+	maxNodes := flag.Int("max-nodes", 100000, "abort once the graph has more than this many nodes, 0 for no limit")
+	maxDepth := flag.Int("max-depth", -1, "deepest level of directories below the root to walk, where 0 only walks the root")
+	continueOnError := flag.Bool("continue-on-error", false, "skip files that cannot be read instead of aborting")
+	reportErrors := flag.Bool("report-errors", false, "list every file that could not be read with its error on stderr")
+	since := flag.String("since", "", "only include diary entries dated on or after this date, e.g. 2023-01-01")
+	until := flag.String("until", "", "only include diary entries dated on or before this date, e.g. 2023-12-31")
+	sizeByDegree := flag.Bool("size-by-degree", false, "size nodes by their number of links")
+	var highlight stringList
+	flag.Var(&highlight, "highlight", "highlight the given node, can be repeated")
+	var collapse stringList
+	flag.Var(&collapse, "collapse", "collapse all files in directory DIR into a single node DIR.wiki, labelled LABEL if given as DIR=LABEL, can be repeated")
+	var ignore stringList
+	flag.Var(&ignore, "ignore", "ignore any files that match the given regex, can be repeated")
+	var exclude stringList
+	flag.Var(&exclude, "exclude-node", "remove the given node and its links, can be repeated")
+	title := flag.String("title", "", "caption of the graph, followed by the time of generation and the number of nodes and edges")
+	legend := flag.Bool("legend", false, "add a legend explaining the styles of nodes and edges")
+	noMetadata := flag.Bool("no-metadata", false, "leave the time of generation and the number of nodes and edges out of the caption")
+	output := flag.String("o", "", "write the graph to this file instead of stdout, compressed if it ends in .gz")
+	format := flag.String("format", "dot", "output format of the graph: dot, gexf, jgf (or json) or edgelist")
+	formats := flag.String("formats", "", "comma separated output formats written to the -o basename with their extension, e.g. dot,jgf")
+	tmplFile := flag.String("template", "", "Go text/template file producing the dot output from the nodes and edges, instead of the built-in drawing")
+	rankdir := flag.String("rankdir", "LR", "direction of the graph layout: TB, LR, BT or RL")
+	layout := flag.String("layout", "", "layout engine used by GraphViz: dot, neato, fdp or circo")
+	mergeReciprocal := flag.Bool("merge-reciprocal", false, "draw files linking to each other with a single edge with arrows on both ends")
+	recordLabels := flag.Bool("record-labels", false, "draw nodes as tables listing their directory and number of outgoing links")
+	list := flag.Bool("list", false, "list each file and its links instead of drawing the graph")
+	duplicates := flag.Bool("duplicates", false, "report file names shared by files in different directories instead of drawing the graph")
+	ambiguous := flag.Bool("ambiguous", false, "report links to file names shared by several files instead of drawing the graph")
+	dirStats := flag.Bool("dir-stats", false, "report the number of files and links within and across each directory instead of drawing the graph")
+	degrees := flag.Bool("degrees", false, "print the number of outgoing and incoming links of each node as TSV instead of drawing the graph")
+	hubs := flag.Int("hubs", 0, "list the notes with more than this number of outgoing links instead of drawing the graph")
+	bridges := flag.Bool("bridges", false, "print pairs of notes sharing tags or words in their names that could link the two largest components instead of drawing the graph")
+	diameter := flag.Bool("diameter", false, "print the longest shortest path between any two notes instead of drawing the graph")
+	tagcloud := flag.String("tagcloud", "", "list the tags by the number of notes carrying them, as text or json, instead of drawing the graph")
+	clustering := flag.Bool("clustering", false, "print the clustering coefficient of the graph and of the most clustered notes instead of drawing the graph")
+	version := flag.Bool("version", false, "print the version of vimwikigraph and of GraphViz dot and exit")
+	pagerank := flag.Int("pagerank", 0, "list the given number of notes with the highest PageRank instead of drawing the graph")
+	degreeMode := flag.String("degree-mode", "total", "links counted against the level: out, in or total")
+	faintBelowLevel := flag.Bool("faint-below-level", false, "draw nodes with less than level number of edges in grey instead of leaving them out")
+	pruneLeaves := flag.Int("prune-leaves", 0, "remove nodes with at most one link for the given number of rounds")
+	minWeight := flag.Int("min-weight", 0, "draw only edges created from at least this many links in their source file")
+	maxEdges := flag.Int("max-edges", 0, "draw at most this many outgoing edges per node, collapsing the rest into a single node")
+	stdin := flag.Bool("stdin", false, "read the files to add from stdin, one path per line, instead of walking the directory")
+	noCache := flag.Bool("no-cache", false, "parse all files, ignoring any previously cached links")
+	cacheDir := flag.String("cache-dir", defaultCacheDir(), "directory to store cached links")
 	flag.Parse()
 
-	// remap any path that contains `diary` into `diary.wiki`
+	if *version {
+		printVersion(os.Stdout)
+		return
+	}
+
+	// fall back to current directory if no directory given
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			log.Fatalf("Error when finding the current directory: %v", err)
+		}
+		dir = wd
+		fmt.Fprintf(os.Stderr, "warning: using current directory: '%s'\n", dir)
+	}
+
+	// expand ~ and environment variables before looking at the path
+	expanded, err := vimwiki.ExpandPath(dir)
+	if err != nil {
+		log.Fatalf("Error when expanding %q: %v", dir, err)
+	}
+	dir = expanded
+
+	// a single note is added on its own, relative to its directory
+	var file string
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		file = dir
+		dir = filepath.Dir(file)
+	}
+
+	if !oneOf(*tagcloud, "", "text", "json") {
+		log.Fatalf("Invalid -tagcloud %q: expected text or json", *tagcloud)
+	}
+	if !oneOf(*format, "dot", "gexf", "jgf", "json", "edgelist") {
+		log.Fatalf("Invalid -format %q: expected dot, gexf, jgf, json or edgelist", *format)
+	}
+	var outputs []string
+	if *formats != "" {
+		for _, f := range strings.Split(*formats, ",") {
+			f = strings.TrimSpace(f)
+			if !oneOf(f, "dot", "gexf", "jgf", "json", "edgelist") {
+				log.Fatalf("Invalid -formats %q: expected dot, gexf, jgf, json or edgelist", f)
+			}
+			outputs = append(outputs, f)
+		}
+		if *output == "" {
+			log.Fatalf("Invalid flags: -formats requires -o")
+		}
+	}
+	if !oneOf(*rankdir, "TB", "LR", "BT", "RL") {
+		log.Fatalf("Invalid -rankdir %q: expected TB, LR, BT or RL", *rankdir)
+	}
+	if *layout != "" && !oneOf(*layout, "dot", "neato", "fdp", "circo") {
+		log.Fatalf("Invalid -layout %q: expected dot, neato, fdp or circo", *layout)
+	}
+	if !oneOf(*defaultExt, "", ".wiki", ".md") {
+		log.Fatalf("Invalid -default-ext %q: expected .wiki or .md", *defaultExt)
+	}
+	if *frontMatterOnly && *frontMatterKey == "" {
+		log.Fatalf("Invalid flags: -frontmatter-only requires -frontmatter-key")
+	}
+	if *cluster && *components {
+		log.Fatalf("Invalid flags: -cluster and -components cannot be combined")
+	}
+	if !oneOf(*diaryBy, "", "week", "month", "year") {
+		log.Fatalf("Invalid -diary-by %q: expected week, month or year", *diaryBy)
+	}
+	if !oneOf(*degreeMode, "out", "in", "total") {
+		log.Fatalf("Invalid -degree-mode %q: expected out, in or total", *degreeMode)
+	}
+
+	ext := ".wiki"
+	if *defaultExt != "" {
+		ext = *defaultExt
+	}
+
+	// remap any path inside the diary, e.g. `diary`, into `diary.wiki`
 	remap := make(map[string]string)
-	if !*diary {
-		remap["diary"] = "diary.wiki"
+	if !*diary && *diaryBy == "" {
+		name := path.Clean(filepath.ToSlash(*diaryDir))
+		remap[name] = name + ext
+	}
+	for _, c := range collapse {
+		name, label := c, ""
+		if idx := strings.Index(c, "="); idx >= 0 {
+			name, label = c[:idx], c[idx+1:]
+		}
+		name = path.Clean(filepath.ToSlash(name))
+		if name == "." || name == "" {
+			log.Fatalf("Invalid -collapse %q: expected a directory inside the wiki", c)
+		}
+		remap[name] = name + ext
+		if label != "" {
+			remap[name] += "|" + label
+		}
 	}
 
 	// setup vimwiki struct
-	wiki, err := newWiki(dir, remap, *cluster, *ignoreRegex)
+	wiki, err := vimwiki.NewWiki(dir, remap, *cluster, ignore...)
 	if err != nil {
 		log.Fatalf("Error in constructor: %v", err)
 	}
+	wiki.NoMarkdown = *noMarkdown
+	wiki.NoWiki = *noWiki
+	wiki.DefaultExt = *defaultExt
+	wiki.FrontMatterKey = *frontMatterKey
+	wiki.FrontMatterOnly = *frontMatterOnly
+	if err := wiki.SetLinkPatterns(*wikiRegex, *markdownRegex); err != nil {
+		log.Fatalf("Invalid -wiki-regex or -markdown-regex: %v", err)
+	}
+	wiki.Titles = *titles
+	wiki.EdgeLabels = *edgeLabels
+	wiki.Undirected = *undirected
+	wiki.MergeReciprocal = *mergeReciprocal
+	wiki.Shapes = *shapes
+	wiki.MergeExtensions = *mergeExtensions
+	wiki.Assets = *assets
+	wiki.Externals = *externals
+	wiki.NoSelfLoops = *noSelfLoops
+	wiki.Tooltips = *tooltips
+	wiki.Meta = *meta
+	wiki.Tasks = *tasks
+	wiki.ColorByAge = *colorByAge
+	wiki.URLs = *urls || *urlBase != ""
+	wiki.URLBase = *urlBase
+	wiki.SizeByDegree = *sizeByDegree
+	wiki.RecordLabels = *recordLabels
+	wiki.MaxEdges = *maxEdges
+	wiki.MinWeight = *minWeight
+	wiki.FaintBelowLevel = *faintBelowLevel
+	wiki.DegreeMode = *degreeMode
+	wiki.ClusterComponents = *components
+	wiki.DiaryBy = *diaryBy
+	wiki.Caption = *title
+	wiki.Metadata = *title != "" && !*noMetadata
+	wiki.Legend = *legend
+	if *aliases != "" {
+		labels, err := readAliases(*aliases)
+		if err != nil {
+			log.Fatalf("Error in -aliases: %v", err)
+		}
+		wiki.Aliases = labels
+	}
+	var tmpl *template.Template
+	if *tmplFile != "" {
+		tmpl, err = readTemplate(*tmplFile)
+		if err != nil {
+			log.Fatalf("Error in -template: %v", err)
+		}
+	}
+	if *progress {
+		wiki.Progress = os.Stderr
+	}
+	wiki.ContinueOnError = *continueOnError
+	wiki.FollowSymlinks = *followSymlinks
+	wiki.IncludeHidden = *includeHidden
+	wiki.MaxNodes = *maxNodes
+	wiki.MaxDepth = *maxDepth
+	if *verbose {
+		wiki.Verbosity = vimwiki.LogDirs
+	}
+	if *veryVerbose {
+		wiki.Verbosity = vimwiki.LogLinks
+	}
+	if !*noCache {
+		if err := wiki.EnableCache(*cacheDir); err != nil {
+			log.Fatalf("Error when loading cache: %v", err)
+		}
+	}
 
 	// any trailing arguments are considered directories to skip
 	subDirToSkip := []string{".git"}
@@ -48,13 +487,281 @@ func main() {
 		subDirToSkip = append(subDirToSkip, dir)
 	}
 
-	// walk directories and build graph
-	if err := wiki.Walk(subDirToSkip); err != nil {
-		log.Fatalf("Error when walking directories: %v", err)
+	// walk directories, or add the given files, and build graph
+	start := time.Now()
+	if file != "" {
+		err = wiki.AddFiles([]string{file})
+	} else if *stdin {
+		err = wiki.AddFiles(readPaths(os.Stdin))
+	} else {
+		err = wiki.Walk(subDirToSkip)
+	}
+	walked := time.Since(start)
+	if *reportErrors {
+		errs := wiki.Errors()
+		paths := make([]string, 0, len(errs))
+		for path := range errs {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, errs[path])
+		}
+	}
+	if err != nil {
+		if errs, ok := err.(vimwiki.WalkErrors); ok {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", errs)
+		} else {
+			log.Fatalf("Error when adding files: %v", err)
+		}
+	}
+
+	// report links with invalid syntax
+	if malformed := wiki.MalformedLinks(); *strict && len(malformed) > 0 {
+		files := make([]string, 0, len(malformed))
+		for file := range malformed {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			for _, m := range malformed[file] {
+				fmt.Fprintf(os.Stderr, "%s:%d: %s: %q\n", file, m.Line, m.Reason, m.Link)
+			}
+		}
+		os.Exit(1)
+	}
+
+	// filter diary entries by their date
+	if *since != "" || *until != "" {
+		var from, to time.Time
+		if *since != "" {
+			if from, err = time.Parse("2006-01-02", *since); err != nil {
+				log.Fatalf("Error in -since: %v", err)
+			}
+		}
+		if *until != "" {
+			if to, err = time.Parse("2006-01-02", *until); err != nil {
+				log.Fatalf("Error in -until: %v", err)
+			}
+		}
+		wiki.FilterByDate(from, to)
+	}
+
+	if *followRedirects {
+		wiki.FollowRedirects()
+	}
+
+	if *crossDirOnly {
+		wiki.CrossDirOnly()
+	}
+
+	if err := wiki.Exclude(exclude...); err != nil {
+		log.Fatalf("Error in -exclude-node: %v", err)
+	}
+
+	// draw exactly the listed nodes
+	if *only != "" {
+		nodes, err := readNodes(*only)
+		if err != nil {
+			log.Fatalf("Error in -only: %v", err)
+		}
+		var unknown []string
+		wiki, unknown = wiki.Only(nodes...)
+		for _, n := range unknown {
+			fmt.Fprintf(os.Stderr, "warning: -only: %v not in graph\n", n)
+		}
+	}
+
+	// keep only the densely connected core
+	if *pruneLeaves > 0 {
+		wiki = wiki.PruneLeaves(*pruneLeaves)
+	}
+
+	// outline the notes below the given root
+	if *tree != "" {
+		t, err := wiki.Tree(*tree)
+		if err != nil {
+			log.Fatalf("Error in -tree: %v", err)
+		}
+		wiki = t
+		if !isSet("rankdir") {
+			*rankdir = "TB"
+		}
+	}
+
+	// summarise the links between directories
+	if *collapseToDirs {
+		wiki = wiki.CollapseToDirs()
+		wiki.EdgeLabels = true
+	}
+
+	// summarise the run once the output, or any report, is written
+	drawn := time.Now()
+	defer func() {
+		if !*timing {
+			return
+		}
+		c := wiki.Counts()
+		fmt.Fprintf(os.Stderr, "walked %d files, added %d (%d cached), %d nodes, %d edges in %v (walk %v, output %v)\n",
+			c.Walked, c.Added, c.Cached, len(wiki.Nodes()), len(wiki.Edges()),
+			time.Since(start).Round(time.Microsecond), walked.Round(time.Microsecond),
+			time.Since(drawn).Round(time.Microsecond))
+	}()
+
+	// report instead of drawing the graph
+	if *list {
+		graph := wiki.Graph()
+		files := make([]string, 0, len(graph))
+		for file := range graph {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			fmt.Println(file)
+			for _, link := range graph[file] {
+				fmt.Printf("\t%s\n", link)
+			}
+		}
+		return
+	}
+
+	if *duplicates {
+		dups := wiki.Duplicates()
+		names := make([]string, 0, len(dups))
+		for name := range dups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s: %s\n", name, strings.Join(dups[name], ", "))
+		}
+		return
+	}
+
+	if *ambiguous {
+		for _, a := range wiki.Ambiguities() {
+			fmt.Printf("%s -> %s: %s\n", a.Source, a.Target, strings.Join(a.Candidates, ", "))
+		}
+		return
+	}
+
+	if *dirStats {
+		stats := wiki.DirStats()
+		dirs := make([]string, 0, len(stats))
+		for dir := range stats {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "directory\tfiles\tlinks within\tlinks across")
+		for _, dir := range dirs {
+			s := stats[dir]
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", dir, s.Files, s.Internal, s.External)
+		}
+		w.Flush()
+		return
+	}
+
+	if *degrees {
+		fmt.Println("node\tout\tin\ttotal")
+		for _, d := range wiki.Degrees() {
+			fmt.Printf("%s\t%d\t%d\t%d\n", d.Node, d.Out, d.In, d.Total())
+		}
+		return
+	}
+
+	if isSet("hubs") {
+		fmt.Println("links\tdirectory\tnode")
+		for _, h := range wiki.Hubs(*hubs) {
+			fmt.Printf("%d\t%s\t%s\n", h.Links, h.Directory, h.Node)
+		}
+		return
+	}
+
+	if *bridges {
+		for _, b := range wiki.SuggestBridges() {
+			fmt.Printf("%s\t%s\t%s\n", b.A, b.B, strings.Join(b.Shared, " "))
+		}
+		return
+	}
+
+	if *diameter {
+		length, chain := wiki.Diameter()
+		fmt.Printf("diameter %d: %s\n", length, strings.Join(chain, " -> "))
+		return
+	}
+
+	switch *tagcloud {
+	case "text":
+		for _, t := range wiki.TagCloud() {
+			fmt.Printf("%d\t%s\n", t.Notes, t.Tag)
+		}
+		return
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(wiki.TagCloud()); err != nil {
+			log.Fatalf("Error when writing tag cloud: %v", err)
+		}
+		return
+	}
+
+	if *clustering {
+		global, local := wiki.ClusteringCoefficient()
+		fmt.Printf("%.4f\tglobal\n", global)
+		for _, n := range vimwiki.TopRanked(local, 10) {
+			fmt.Printf("%.4f\t%s\n", local[n], n)
+		}
+		return
+	}
+
+	if *pagerank > 0 {
+		rank := wiki.PageRank(100, 0.85)
+		for _, n := range vimwiki.TopRanked(rank, *pagerank) {
+			fmt.Printf("%.4f\t%s\n", rank[n], n)
+		}
+		return
+	}
+
+	if err := wiki.Highlight(highlight...); err != nil {
+		log.Fatalf("Error in -highlight: %v", err)
+	}
+
+	emitters := map[string]func(io.Writer) error{
+		"gexf": wiki.GEXF,
+		"jgf":  wiki.JGF,
+		"json": wiki.JGF,
+		"edgelist": func(w io.Writer) error {
+			return wiki.EdgeList(*level, w)
+		},
+		"dot": func(w io.Writer) error {
+			if tmpl != nil {
+				return wiki.DotTemplate(tmpl, w)
+			}
+
+			// convert to a dot-graph for visualisation
+			g := wiki.Dot(*level, dot.Directed)
+			g.Attr("rankdir", *rankdir)
+			if *layout != "" {
+				g.Attr("layout", *layout)
+			}
+			g.Write(w)
+			return nil
+		},
+	}
+
+	// write each format to the basename with its extension
+	if len(outputs) > 0 {
+		for _, f := range outputs {
+			path := outputPath(*output, f)
+			if err := writeFile(path, emitters[f]); err != nil {
+				log.Fatalf("Error when writing %v: %v", path, err)
+			}
+		}
+		return
 	}
 
-	// convert to a dot-graph for visualisation
-	g := wiki.Dot(*level, dot.Directed)
-	g.Attr("rankdir", "LR")
-	g.Write(os.Stdout)
+	if err := writeFile(*output, emitters[*format]); err != nil {
+		log.Fatalf("Error when writing output: %v", err)
+	}
 }
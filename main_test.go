@@ -0,0 +1,69 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maxvdkolk/vimwikigraph/vimwiki"
+)
+
+func TestOutputPath(t *testing.T) {
+	cases := map[[2]string]string{
+		{"graph", "dot"}:     "graph.dot",
+		{"graph", "jgf"}:     "graph.json",
+		{"graph", "json"}:    "graph.json",
+		{"graph.gz", "dot"}:  "graph.dot.gz",
+		{"graph.gz", "gexf"}: "graph.gexf.gz",
+	}
+	for args, exp := range cases {
+		if got := outputPath(args[0], args[1]); got != exp {
+			t.Errorf("Expected %v for %v, got %v", exp, args, got)
+		}
+	}
+}
+
+func TestWriteFileCompressed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wiki, err := vimwiki.NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	path := outputPath(filepath.Join(dir, "graph.gz"), "jgf")
+	if !strings.HasSuffix(path, ".json.gz") {
+		t.Fatalf("Expected a compressed json file, got %v", path)
+	}
+	if err := writeFile(path, wiki.JGF); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected gzip output: %v", err)
+	}
+	var graph map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&graph); err != nil {
+		t.Fatalf("Expected valid JSON after decompressing: %v", err)
+	}
+	if _, ok := graph["graph"]; !ok {
+		t.Errorf("Expected a JSON graph, got %v", graph)
+	}
+}
@@ -0,0 +1,483 @@
+package vimwiki
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Duplicates maps each file name that is shared by files in different
+// directories to the sorted paths of those files, e.g. note.wiki to
+// a/note.wiki and b/note.wiki. Unique file names are not included.
+func (wiki *Wiki) Duplicates() map[string][]string {
+	paths := make(map[string][]string)
+	for _, n := range wiki.nodes() {
+		base := path.Base(n)
+		paths[base] = append(paths[base], n)
+	}
+
+	dups := make(map[string][]string)
+	for base, p := range paths {
+		if len(p) > 1 {
+			sort.Strings(p)
+			dups[base] = p
+		}
+	}
+	return dups
+}
+
+// PageRank returns the PageRank of each node computed by power iteration over
+// the directed graph with the given damping factor, typically 0.85. The rank
+// of nodes without outgoing links is distributed evenly over all nodes. The
+// ranks sum up to one.
+func (wiki *Wiki) PageRank(iterations int, damping float64) map[string]float64 {
+	nodes := wiki.nodes()
+	n := float64(len(nodes))
+
+	rank := make(map[string]float64)
+	for _, v := range nodes {
+		rank[v] = 1 / n
+	}
+
+	for i := 0; i < iterations; i++ {
+		// rank of nodes without outgoing links
+		var dangling float64
+		for _, v := range nodes {
+			if len(wiki.graph[v]) == 0 {
+				dangling += rank[v]
+			}
+		}
+
+		next := make(map[string]float64)
+		for _, v := range nodes {
+			next[v] = (1-damping)/n + damping*dangling/n
+		}
+		for k, val := range wiki.graph {
+			for _, v := range val {
+				next[v] += damping * rank[k] / float64(len(val))
+			}
+		}
+		rank = next
+	}
+	return rank
+}
+
+// TopRanked returns the names of at most n nodes with the highest rank,
+// sorted by decreasing rank and by name for equal ranks.
+func TopRanked(rank map[string]float64, n int) []string {
+	nodes := make([]string, 0, len(rank))
+	for v := range rank {
+		nodes = append(nodes, v)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if rank[nodes[i]] != rank[nodes[j]] {
+			return rank[nodes[i]] > rank[nodes[j]]
+		}
+		return nodes[i] < nodes[j]
+	})
+	if len(nodes) > n {
+		nodes = nodes[:n]
+	}
+	return nodes
+}
+
+// Components returns the weakly connected components of the graph, i.e. the
+// groups of nodes that are connected when ignoring the direction of links.
+// Each component is sorted, and the components are sorted by decreasing size
+// and by their first node for equal sizes.
+func (wiki *Wiki) Components() [][]string {
+	rev := wiki.reverse()
+	seen := make(map[string]bool)
+
+	var components [][]string
+	for _, n := range wiki.nodes() {
+		if seen[n] {
+			continue
+		}
+
+		// collect all nodes reachable in either direction
+		seen[n] = true
+		component := []string{}
+		queue := []string{n}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			component = append(component, v)
+			for _, neighbours := range [][]string{wiki.graph[v], rev[v]} {
+				for _, w := range neighbours {
+					if !seen[w] {
+						seen[w] = true
+						queue = append(queue, w)
+					}
+				}
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+	return components
+}
+
+// Ambiguity is a link to a file whose name is shared by several files, such
+// that the link may not refer to the intended file.
+type Ambiguity struct {
+	// File containing the link
+	Source string
+	// File the link is resolved to
+	Target string
+	// Sorted files with the same name as Target
+	Candidates []string
+}
+
+// Ambiguities returns all links whose target has the same file name as more
+// than one file in the wiki, e.g. [[note]] when both a/note.wiki and
+// b/note.wiki exist, sorted by source and target. Links are considered before
+// renaming.
+func (wiki *Wiki) Ambiguities() []Ambiguity {
+	var ambiguities []Ambiguity
+	for link := range wiki.resolved {
+		candidates := wiki.files[path.Base(link[1])]
+		if len(candidates) < 2 {
+			continue
+		}
+		candidates = append([]string{}, candidates...)
+		sort.Strings(candidates)
+		ambiguities = append(ambiguities, Ambiguity{link[0], link[1], candidates})
+	}
+
+	sort.Slice(ambiguities, func(i, j int) bool {
+		a, b := ambiguities[i], ambiguities[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Target < b.Target
+	})
+	return ambiguities
+}
+
+// DirStat holds the number of files in a directory and the number of links
+// from these files that stay within the directory or cross into another.
+type DirStat struct {
+	Files    int
+	Internal int
+	External int
+}
+
+// DirStats returns the statistics of each directory containing files, where
+// the root directory is denoted by ".". Links to external websites are not
+// counted.
+func (wiki *Wiki) DirStats() map[string]DirStat {
+	stats := make(map[string]DirStat)
+	for k, val := range wiki.graph {
+		dir := path.Dir(k)
+		s := stats[dir]
+		s.Files++
+		for _, v := range val {
+			if wiki.externals[v] {
+				continue
+			}
+			if path.Dir(v) == dir {
+				s.Internal++
+			} else {
+				s.External++
+			}
+		}
+		stats[dir] = s
+	}
+	return stats
+}
+
+// DirGraph returns the graph of directories, mapping each directory containing
+// files to the number of links from its files to the files of each other
+// directory, where the root directory is denoted by ".". Links within a
+// directory and to external websites or other wikis are not counted.
+func (wiki *Wiki) DirGraph() map[string]map[string]int {
+	dirs := make(map[string]map[string]int)
+	for _, n := range wiki.nodes() {
+		if dir := wiki.directory(n); dir != "" {
+			dirs[dir] = make(map[string]int)
+		}
+	}
+	for k, val := range wiki.graph {
+		from := wiki.directory(k)
+		for _, v := range val {
+			to := wiki.directory(v)
+			if from == "" || to == "" || from == to {
+				continue
+			}
+			dirs[from][to]++
+		}
+	}
+	return dirs
+}
+
+// Degree holds the number of outgoing and incoming links of a node.
+type Degree struct {
+	Node string
+	Out  int
+	In   int
+}
+
+// Total returns the total number of links of the node.
+func (d Degree) Total() int {
+	return d.Out + d.In
+}
+
+// Degrees returns the degree of every node, sorted by decreasing total degree
+// and by name for equal degrees.
+func (wiki *Wiki) Degrees() []Degree {
+	out, in := wiki.degrees()
+	nodes := wiki.nodes()
+
+	degrees := make([]Degree, len(nodes))
+	for i, n := range nodes {
+		degrees[i] = Degree{Node: n, Out: out[n], In: in[n]}
+	}
+	sort.SliceStable(degrees, func(i, j int) bool {
+		return degrees[i].Total() > degrees[j].Total()
+	})
+	return degrees
+}
+
+// Hub is a note with many outgoing links, see Hubs.
+type Hub struct {
+	Node      string
+	Directory string
+	Links     int
+}
+
+// Hubs returns every note with more than n outgoing links, sorted by
+// decreasing number of links and by name for equal numbers, e.g. to find
+// notes that should be split up.
+func (wiki *Wiki) Hubs(n int) []Hub {
+	var hubs []Hub
+	for k, val := range wiki.graph {
+		if len(val) > n {
+			hubs = append(hubs, Hub{Node: k, Directory: wiki.directory(k), Links: len(val)})
+		}
+	}
+	sort.Slice(hubs, func(i, j int) bool {
+		if hubs[i].Links != hubs[j].Links {
+			return hubs[i].Links > hubs[j].Links
+		}
+		return hubs[i].Node < hubs[j].Node
+	})
+	return hubs
+}
+
+// Diameter returns the length of the longest shortest path between any two
+// nodes of the largest component, ignoring the direction of links, together
+// with an example of such a path. Zero and no path are returned for an empty
+// graph.
+func (wiki *Wiki) Diameter() (int, []string) {
+	components := wiki.Components()
+	if len(components) == 0 {
+		return 0, nil
+	}
+
+	// sorted neighbours in either direction for a deterministic path
+	rev := wiki.reverse()
+	neighbours := make(map[string][]string)
+	for _, v := range components[0] {
+		seen := make(map[string]bool)
+		for _, w := range append(append([]string{}, wiki.graph[v]...), rev[v]...) {
+			if !seen[w] && w != v {
+				seen[w] = true
+				neighbours[v] = append(neighbours[v], w)
+			}
+		}
+		sort.Strings(neighbours[v])
+	}
+
+	diameter, longest := 0, []string{components[0][0]}
+	for _, source := range components[0] {
+		// breadth first search from source
+		dist := map[string]int{source: 0}
+		prev := make(map[string]string)
+		queue := []string{source}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for _, w := range neighbours[v] {
+				if _, ok := dist[w]; !ok {
+					dist[w] = dist[v] + 1
+					prev[w] = v
+					queue = append(queue, w)
+				}
+			}
+			if dist[v] > diameter {
+				diameter = dist[v]
+				longest = []string{v}
+				for u := v; u != source; {
+					u = prev[u]
+					longest = append([]string{u}, longest...)
+				}
+			}
+		}
+	}
+	return diameter, longest
+}
+
+// ClusteringCoefficient returns the local clustering coefficient of each
+// node, i.e. the fraction of pairs of its neighbours that are linked to each
+// other, and their average over all nodes as global coefficient. The direction
+// of links and links from a node to itself are ignored. Nodes with less than
+// two neighbours have a coefficient of zero.
+func (wiki *Wiki) ClusteringCoefficient() (global float64, local map[string]float64) {
+	neighbours := make(map[string]map[string]bool)
+	link := func(a, b string) {
+		if neighbours[a] == nil {
+			neighbours[a] = make(map[string]bool)
+		}
+		neighbours[a][b] = true
+	}
+	for k, val := range wiki.graph {
+		for _, v := range val {
+			if k != v {
+				link(k, v)
+				link(v, k)
+			}
+		}
+	}
+
+	nodes := wiki.nodes()
+	local = make(map[string]float64, len(nodes))
+	for _, n := range nodes {
+		k := len(neighbours[n])
+		if k < 2 {
+			local[n] = 0
+			continue
+		}
+		links := 0
+		for a := range neighbours[n] {
+			for b := range neighbours[n] {
+				if a < b && neighbours[a][b] {
+					links++
+				}
+			}
+		}
+		local[n] = 2 * float64(links) / float64(k*(k-1))
+		global += local[n]
+	}
+	if len(nodes) > 0 {
+		global /= float64(len(nodes))
+	}
+	return global, local
+}
+
+// MalformedLinks returns the links with invalid syntax, e.g. [[]], of each
+// file, see Parser.Malformed. Files without such links are not included.
+func (wiki *Wiki) MalformedLinks() map[string][]Malformed {
+	return wiki.malformed
+}
+
+// TagCount is the number of files carrying a tag.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Notes int    `json:"notes"`
+}
+
+// TagCloud returns every tag, i.e. :tag:, together with the number of files
+// carrying it, sorted by decreasing number of files and by tag for equal
+// numbers. Files collapsed into a single node are counted separately.
+func (wiki *Wiki) TagCloud() []TagCount {
+	counts := make(map[string]int)
+	for _, tags := range wiki.tags {
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+
+	cloud := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		cloud = append(cloud, TagCount{tag, n})
+	}
+	sort.Slice(cloud, func(i, j int) bool {
+		if cloud[i].Notes != cloud[j].Notes {
+			return cloud[i].Notes > cloud[j].Notes
+		}
+		return cloud[i].Tag < cloud[j].Tag
+	})
+	return cloud
+}
+
+// Bridge is a pair of notes in different components that could be linked to
+// connect them, see SuggestBridges.
+type Bridge struct {
+	A, B string
+	// Tags, i.e. :tag:, and words of the file names shared by both notes
+	Shared []string
+}
+
+// SuggestBridges returns the pairs of notes of the two largest components
+// that share any tags or words in their file names, e.g. a/vim-tips.wiki and
+// b/vim.wiki, as candidates to link the components to each other. The pairs
+// are sorted by decreasing number of shared tags and words and by name for
+// equal numbers. No pairs are returned for fewer than two components.
+func (wiki *Wiki) SuggestBridges() []Bridge {
+	components := wiki.Components()
+	if len(components) < 2 {
+		return nil
+	}
+
+	// the tags and words of the name of each note
+	keys := func(n string) map[string]bool {
+		k := make(map[string]bool)
+		for _, tag := range wiki.tags[n] {
+			k[":"+tag+":"] = true
+		}
+		base := strings.TrimSuffix(path.Base(n), path.Ext(n))
+		for _, w := range strings.FieldsFunc(strings.ToLower(base), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		}) {
+			// skip short words, e.g. a or of
+			if len([]rune(w)) > 2 {
+				k[w] = true
+			}
+		}
+		return k
+	}
+	note := func(n string) bool {
+		return !wiki.externals[n] && !wiki.interwikis[n] && !wiki.assets[n]
+	}
+
+	other := make(map[string]map[string]bool)
+	for _, b := range components[1] {
+		if note(b) {
+			other[b] = keys(b)
+		}
+	}
+
+	var bridges []Bridge
+	for _, a := range components[0] {
+		if !note(a) {
+			continue
+		}
+		ka := keys(a)
+		for _, b := range components[1] {
+			kb, ok := other[b]
+			if !ok {
+				continue
+			}
+			var shared []string
+			for k := range kb {
+				if ka[k] {
+					shared = append(shared, k)
+				}
+			}
+			if len(shared) > 0 {
+				sort.Strings(shared)
+				bridges = append(bridges, Bridge{A: a, B: b, Shared: shared})
+			}
+		}
+	}
+	sort.SliceStable(bridges, func(i, j int) bool {
+		return len(bridges[i].Shared) > len(bridges[j].Shared)
+	})
+	return bridges
+}
@@ -0,0 +1,326 @@
+package vimwiki
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	wiki := Wiki{graph: map[string][]string{
+		"a/note.wiki": {"b/note.wiki", "index.wiki"},
+		"index.wiki":  {"note.wiki", "other.wiki"},
+	}}
+
+	dups := wiki.Duplicates()
+	if len(dups) != 1 {
+		t.Errorf("Expected one duplicated name, got %v", dups)
+	}
+	exp := "[a/note.wiki b/note.wiki note.wiki]"
+	if got := fmt.Sprint(dups["note.wiki"]); got != exp {
+		t.Errorf("Expected duplicates %v, got %v", exp, got)
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	// a links to b, which has no outgoing links, such that:
+	//   PR(a) = (1-d)/2 + d*PR(b)/2
+	//   PR(b) = (1-d)/2 + d*(PR(a) + PR(b)/2)
+	// with PR(a) + PR(b) = 1, i.e. PR(a) = 0.5/(1 + d/2)
+	wiki := Wiki{graph: map[string][]string{"a.wiki": {"b.wiki"}}}
+
+	d := 0.85
+	rank := wiki.PageRank(100, d)
+	exp := map[string]float64{"a.wiki": 0.5 / (1 + d/2), "b.wiki": 1 - 0.5/(1+d/2)}
+	for n, r := range exp {
+		if math.Abs(rank[n]-r) > 1e-9 {
+			t.Errorf("Expected rank %v for %v, got %v", r, n, rank[n])
+		}
+	}
+
+	if top := fmt.Sprint(TopRanked(rank, 1)); top != "[b.wiki]" {
+		t.Errorf("Expected b.wiki to be ranked highest, got %v", top)
+	}
+
+	// a cycle ranks all nodes equally
+	wiki.graph = map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}}
+	for n, r := range wiki.PageRank(50, d) {
+		if math.Abs(r-1.0/3) > 1e-9 {
+			t.Errorf("Expected rank 1/3 for %v, got %v", n, r)
+		}
+	}
+}
+
+func TestComponents(t *testing.T) {
+	wiki := Wiki{graph: map[string][]string{
+		"a.wiki": {"b.wiki"},
+		"c.wiki": {"b.wiki"},
+		"d.wiki": {"e.wiki"},
+		"f.wiki": {},
+	}}
+
+	exp := "[[a.wiki b.wiki c.wiki] [d.wiki e.wiki] [f.wiki]]"
+	if got := fmt.Sprint(wiki.Components()); got != exp {
+		t.Errorf("Expected components %v, got %v", exp, got)
+	}
+}
+
+func TestAmbiguities(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":  "[[a/note]] [[other]]",
+		"a/note.wiki": "[[note]] [[/index]]",
+		"b/note.wiki": "",
+		"other.wiki":  "",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, map[string]string{"b": "b.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "[{a/note.wiki a/note.wiki [a/note.wiki b/note.wiki]} " +
+		"{index.wiki a/note.wiki [a/note.wiki b/note.wiki]}]"
+	if got := fmt.Sprint(wiki.Ambiguities()); got != exp {
+		t.Errorf("Expected ambiguities %v, got %v", exp, got)
+	}
+}
+
+func TestDirStats(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki":  {"a/x.wiki", "other.wiki", "https://a.com"},
+			"a/x.wiki":    {"a/y.wiki", "a/b/z.wiki"},
+			"a/y.wiki":    {"a/x.wiki"},
+			"a/b/z.wiki":  {},
+			"other.wiki":  {},
+			"b/note.wiki": {"index.wiki"},
+		},
+		externals: map[string]bool{"https://a.com": true},
+	}
+
+	exp := map[string]DirStat{
+		".":   {Files: 2, Internal: 1, External: 1},
+		"a":   {Files: 2, Internal: 2, External: 1},
+		"a/b": {Files: 1},
+		"b":   {Files: 1, External: 1},
+	}
+	stats := wiki.DirStats()
+	if len(stats) != len(exp) {
+		t.Errorf("Expected %v directories, got %v", len(exp), stats)
+	}
+	for dir, s := range exp {
+		if stats[dir] != s {
+			t.Errorf("Expected %+v for %v, got %+v", s, dir, stats[dir])
+		}
+	}
+}
+
+func TestDegreesTable(t *testing.T) {
+	wiki := Wiki{graph: map[string][]string{
+		"b.wiki": {"a.wiki", "c.wiki"},
+		"a.wiki": {"c.wiki"},
+		"d.wiki": {},
+	}}
+
+	exp := "[{a.wiki 1 1} {b.wiki 2 0} {c.wiki 0 2} {d.wiki 0 0}]"
+	if got := fmt.Sprint(wiki.Degrees()); got != exp {
+		t.Errorf("Expected degrees %v, got %v", exp, got)
+	}
+}
+
+func TestDiameter(t *testing.T) {
+	// a chain of four nodes, whose direction of links is ignored, and a
+	// smaller separate component
+	wiki := Wiki{graph: map[string][]string{
+		"a.wiki": {"b.wiki"},
+		"c.wiki": {"b.wiki", "d.wiki"},
+		"x.wiki": {"y.wiki"},
+	}}
+
+	diameter, path := wiki.Diameter()
+	if diameter != 3 {
+		t.Errorf("Expected diameter 3, got %v", diameter)
+	}
+	exp := "[a.wiki b.wiki c.wiki d.wiki]"
+	if got := fmt.Sprint(path); got != exp {
+		t.Errorf("Expected path %v, got %v", exp, got)
+	}
+
+	wiki.graph = map[string][]string{}
+	if diameter, path := wiki.Diameter(); diameter != 0 || path != nil {
+		t.Errorf("Expected no diameter for an empty graph, got %v %v", diameter, path)
+	}
+}
+
+func TestMalformedLinks(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki": "[[a]]\n[[ ]]",
+		"a.wiki":     "[[index]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "map[index.wiki:[{[[ ]] empty link 2}]]"
+	if got := fmt.Sprint(wiki.MalformedLinks()); got != exp {
+		t.Errorf("Expected malformed links %v, got %v", exp, got)
+	}
+}
+
+func TestClusteringCoefficient(t *testing.T) {
+	// every pair of neighbours in a triangle is linked
+	wiki := Wiki{graph: map[string][]string{
+		"a.wiki": {"b.wiki"},
+		"b.wiki": {"c.wiki"},
+		"c.wiki": {"a.wiki", "c.wiki"},
+	}}
+	global, local := wiki.ClusteringCoefficient()
+	if global != 1 {
+		t.Errorf("Expected coefficient 1 for a triangle, got %v", global)
+	}
+	for n, c := range local {
+		if c != 1 {
+			t.Errorf("Expected coefficient 1 for %v, got %v", n, c)
+		}
+	}
+
+	// no neighbours of the centre of a star are linked
+	wiki.graph = map[string][]string{
+		"index.wiki": {"a.wiki", "b.wiki", "c.wiki"},
+		"a.wiki":     {"index.wiki"},
+	}
+	global, local = wiki.ClusteringCoefficient()
+	if global != 0 || len(local) != 4 {
+		t.Errorf("Expected coefficient 0 for all nodes of a star, got %v %v", global, local)
+	}
+
+	// a triangle with an extra leaf
+	wiki.graph = map[string][]string{
+		"a.wiki": {"b.wiki", "c.wiki", "d.wiki"},
+		"b.wiki": {"c.wiki"},
+	}
+	global, local = wiki.ClusteringCoefficient()
+	if math.Abs(local["a.wiki"]-1.0/3) > 1e-9 || local["b.wiki"] != 1 || local["d.wiki"] != 0 {
+		t.Errorf("Expected coefficients 1/3, 1 and 0, got %v", local)
+	}
+	if exp := (1.0/3 + 2) / 4; math.Abs(global-exp) > 1e-9 {
+		t.Errorf("Expected global coefficient %v, got %v", exp, global)
+	}
+}
+
+func TestTagCloud(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki":       ":work:idea:\n:work:",
+		"b.wiki":       ":idea:",
+		"c.wiki":       ":home: :idea:",
+		"diary/d.wiki": ":work:",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, map[string]string{"diary": "diary.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "[{idea 3} {work 2} {home 1}]"
+	if got := fmt.Sprint(wiki.TagCloud()); got != exp {
+		t.Errorf("Expected tag cloud %v, got %v", exp, got)
+	}
+}
+
+func TestDirGraph(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki":  {"a/x.wiki", "b/y.wiki", "https://a.com"},
+			"a/x.wiki":    {"a/z.wiki", "b/y.wiki", "c/w.wiki"},
+			"a/z.wiki":    {"b/y.wiki"},
+			"b/y.wiki":    {"a/x.wiki"},
+			"c/w.wiki":    {},
+			"c/note.wiki": {"c/w.wiki"},
+		},
+		externals: map[string]bool{"https://a.com": true},
+	}
+
+	exp := "map[.:map[a:1 b:1] a:map[b:2 c:1] b:map[a:1] c:map[]]"
+	if got := fmt.Sprint(wiki.DirGraph()); got != exp {
+		t.Errorf("Expected directory graph %v, got %v", exp, got)
+	}
+
+	c := wiki.CollapseToDirs()
+	if got := fmt.Sprint(c.Edges()); got != "[[. a] [. b] [a b] [a c] [b a]]" {
+		t.Errorf("Expected edges between directories, got %v", got)
+	}
+	if e, ok := c.Edge("a", "b"); !ok || e.Weight != 2 || e.Label != "2" {
+		t.Errorf("Expected two links from a to b, got %+v", e)
+	}
+	if len(wiki.graph) != 6 {
+		t.Errorf("Expected the original graph to be unchanged, got %v", wiki.graph)
+	}
+}
+
+func TestHubs(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki": {"a/x.wiki", "a/y.wiki", "b.wiki", "https://a.com"},
+			"a/x.wiki":   {"a/y.wiki", "b.wiki", "index.wiki"},
+			"a/y.wiki":   {"b.wiki", "index.wiki", "a/x.wiki"},
+			"b.wiki":     {"index.wiki", "a/x.wiki"},
+			"c.wiki":     {},
+		},
+		externals: map[string]bool{"https://a.com": true},
+	}
+
+	exp := "[{index.wiki . 4} {a/x.wiki a 3} {a/y.wiki a 3}]"
+	if got := fmt.Sprint(wiki.Hubs(2)); got != exp {
+		t.Errorf("Expected hubs %v, got %v", exp, got)
+	}
+	if hubs := wiki.Hubs(4); len(hubs) != 0 {
+		t.Errorf("Expected no notes above the threshold, got %v", hubs)
+	}
+	if hubs := wiki.Hubs(0); len(hubs) != 4 {
+		t.Errorf("Expected all notes with links, got %v", hubs)
+	}
+}
+
+func TestSuggestBridges(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"work/index.wiki":    {"work/vim-tips.wiki", "work/go.wiki", "https://vim.org"},
+			"work/vim-tips.wiki": {"work/go.wiki"},
+			"home/vim.wiki":      {"home/recipes.wiki"},
+			"home/recipes.wiki":  {},
+			"other/a.wiki":       {},
+		},
+		tags: map[string][]string{
+			"work/go.wiki":      {"code", "idea"},
+			"home/recipes.wiki": {"idea"},
+			"home/vim.wiki":     {"code"},
+		},
+		externals: map[string]bool{"https://vim.org": true},
+	}
+
+	exp := "[{work/go.wiki home/recipes.wiki [:idea:]} " +
+		"{work/go.wiki home/vim.wiki [:code:]} " +
+		"{work/vim-tips.wiki home/vim.wiki [vim]}]"
+	if got := fmt.Sprint(wiki.SuggestBridges()); got != exp {
+		t.Errorf("Expected bridges %v, got %v", exp, got)
+	}
+
+	wiki.graph["home/vim.wiki"] = append(wiki.graph["home/vim.wiki"], "work/index.wiki")
+	if bridges := wiki.SuggestBridges(); len(bridges) != 0 {
+		t.Errorf("Expected no bridges to the component of a.wiki, got %v", bridges)
+	}
+}
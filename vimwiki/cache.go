@@ -0,0 +1,109 @@
+package vimwiki
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion identifies the layout of the cached pages. Caches written with
+// a different version are discarded.
+const cacheVersion = 21
+
+// cache stores the page extracted from each file together with the
+// modification time of the file at the moment it was parsed.
+type cache struct {
+	// Location of the cache file on disk
+	path string
+	// Cached pages per file path
+	entries map[string]cacheEntry
+	// Paths requested during this run, which are known to exist
+	used map[string]bool
+}
+
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+type cacheEntry struct {
+	ModTime time.Time `json:"mtime"`
+	// Options of the parser that extracted the page
+	Options string `json:"options"`
+	Page
+}
+
+// loadCache reads the cache for the wiki at root from dir. A missing or
+// unreadable cache file results in an empty cache.
+func loadCache(dir, root string) (*cache, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// every wiki root gets its own cache file
+	sum := sha1.Sum([]byte(abs))
+	c := cache{
+		path:    filepath.Join(dir, hex.EncodeToString(sum[:])+".json"),
+		entries: make(map[string]cacheEntry),
+		used:    make(map[string]bool),
+	}
+
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return &c, nil
+	}
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil || f.Version != cacheVersion {
+		return &c, nil
+	}
+	if f.Entries != nil {
+		c.entries = f.Entries
+	}
+	return &c, nil
+}
+
+// get returns the cached page of path when its modification time is
+// identical to mtime and it is extracted by a parser with the same options.
+func (c *cache) get(path string, mtime time.Time, options string) (Page, bool) {
+	entry, ok := c.entries[path]
+	if !ok || !entry.ModTime.Equal(mtime) || entry.Options != options {
+		return Page{}, false
+	}
+	c.used[path] = true
+	return entry.Page, true
+}
+
+// put stores the page of path parsed at modification time mtime by a parser
+// with the given options.
+func (c *cache) put(path string, mtime time.Time, options string, page Page) {
+	c.entries[path] = cacheEntry{ModTime: mtime, Options: options, Page: page}
+	c.used[path] = true
+}
+
+// save writes the entries to disk. Entries that are not used during this
+// run, e.g. when only some of the files are added, are kept unless their file
+// has been removed from the wiki.
+func (c *cache) save() error {
+	for path := range c.entries {
+		if c.used[path] {
+			continue
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(c.entries, path)
+		}
+	}
+
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, data, 0600)
+}
@@ -0,0 +1,203 @@
+package vimwiki
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheModTime(t *testing.T) {
+	root, err := ioutil.TempDir("", "wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	cacheDir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	touched := filepath.Join(root, "touched.wiki")
+	untouched := filepath.Join(root, "untouched.wiki")
+	for path, text := range map[string]string{touched: "[[a]]", untouched: "[[b]]"} {
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	walk := func() *Wiki {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wiki.EnableCache(cacheDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+		return wiki
+	}
+	walk()
+
+	// change both files, but only update the modification time of one
+	info, err := os.Stat(untouched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(touched, []byte("[[c]]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(touched, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(untouched, []byte("[[d]]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(untouched, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	wiki := walk()
+	if links := wiki.graph["touched.wiki"]; len(links) != 1 || links[0] != "c.wiki" {
+		t.Errorf("Expected touched file to be parsed again, got %v", links)
+	}
+	if links := wiki.graph["untouched.wiki"]; len(links) != 1 || links[0] != "b.wiki" {
+		t.Errorf("Expected untouched file to be served from cache, got %v", links)
+	}
+	if c := wiki.Counts(); c != (Counts{Walked: 2, Added: 2, Cached: 1}) {
+		t.Errorf("Expected two files added of which one from cache, got %+v", c)
+	}
+}
+
+func TestCacheOptions(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{"note.wiki": "[[a]] [b](b)"})
+	defer clean()
+
+	cacheDir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	// pages extracted with different options are not shared
+	for _, noMarkdown := range []bool{false, true, false} {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.NoMarkdown = noMarkdown
+		if err := wiki.EnableCache(cacheDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := 2
+		if noMarkdown {
+			exp = 1
+		}
+		if links := wiki.graph["note.wiki"]; len(links) != exp {
+			t.Errorf("Expected %v links without markdown %v, got %v", exp, noMarkdown, links)
+		}
+	}
+}
+
+func TestCachePartialRun(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki":     "[[b]]",
+		"b.wiki":     "[[c]]",
+		"c.wiki":     "[[a]]",
+		"sub/d.wiki": "[[../a]]",
+	})
+	defer clean()
+
+	cacheDir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	newWiki := func() *Wiki {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wiki.EnableCache(cacheDir); err != nil {
+			t.Fatal(err)
+		}
+		return wiki
+	}
+	if err := newWiki().Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// adding a single file keeps the entries of the other files
+	if err := newWiki().AddFiles([]string{filepath.Join(root, "a.wiki")}); err != nil {
+		t.Fatal(err)
+	}
+	wiki := newWiki()
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+	if c := wiki.Counts(); c.Cached != 4 {
+		t.Errorf("Expected all files from cache after a partial run, got %+v", c)
+	}
+
+	// removed files are dropped from the cache
+	if err := os.Remove(filepath.Join(root, "sub", "d.wiki")); err != nil {
+		t.Fatal(err)
+	}
+	if err := newWiki().AddFiles([]string{filepath.Join(root, "a.wiki")}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadCache(cacheDir, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.entries) != 3 {
+		t.Errorf("Expected 3 cached files after removing one, got %v", len(c.entries))
+	}
+}
+
+func TestCacheSaveFailure(t *testing.T) {
+	root, err := ioutil.TempDir("", "wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := ioutil.WriteFile(filepath.Join(root, "index.wiki"), []byte("[[a]]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	wiki.Log = &log
+
+	// the cache directory cannot be created below a regular file
+	dir := filepath.Join(root, "index.wiki", "cache")
+	if err := wiki.EnableCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatalf("walk fails when the cache cannot be saved: %v", err)
+	}
+	if !strings.Contains(log.String(), "not saving cache") {
+		t.Errorf("expected a warning about the cache, got %q", log.String())
+	}
+	if len(wiki.Edges()) != 1 {
+		t.Errorf("expected 1 edge, got %v", wiki.Edges())
+	}
+}
@@ -0,0 +1,331 @@
+package vimwiki
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"text/template"
+)
+
+// label returns the label of the node of path as drawn by Dot, i.e. its
+// alias, the label in the remap of its directory, the first heading of its
+// file if wiki.Titles == true, or its original name.
+func (wiki *Wiki) label(p string) string {
+	if alias, ok := wiki.Aliases[p]; ok {
+		return alias
+	}
+	if label, ok := wiki.labels[p]; ok {
+		return label
+	}
+	if title, ok := wiki.titles[p]; wiki.Titles && ok {
+		return title
+	}
+	if original, ok := wiki.names[p]; ok {
+		return original
+	}
+	return p
+}
+
+// directory returns the directory of the file of the node of path, or an
+// empty string for external websites and pages in other wikis.
+func (wiki *Wiki) directory(p string) string {
+	if wiki.externals[p] || wiki.interwikis[p] {
+		return ""
+	}
+	return path.Dir(p)
+}
+
+type gexf struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Creator string    `xml:"meta>creator"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	EdgeType   string         `xml:"defaultedgetype,attr"`
+	Mode       string         `xml:"mode,attr"`
+	Attributes gexfAttributes `xml:"attributes"`
+	Nodes      []gexfNode     `xml:"nodes>node"`
+	Edges      []gexfEdge     `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+type gexfValue struct {
+	For   string      `xml:"for,attr"`
+	Value interface{} `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     int    `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Weight int    `xml:"weight,attr"`
+}
+
+// GEXF writes the graph to w as a GEXF 1.3 document, e.g. for analysis in
+// Gephi, including all nodes and links. Each node is identified by its path
+// and has the attributes directory, outdegree and indegree. Edges are
+// weighted by their number of links.
+func (wiki *Wiki) GEXF(w io.Writer) error {
+	out, in := wiki.degrees()
+	doc := gexf{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Creator: "vimwikigraph",
+		Graph: gexfGraph{
+			EdgeType: "directed",
+			Mode:     "static",
+			Attributes: gexfAttributes{
+				Class: "node",
+				Attributes: []gexfAttribute{
+					{ID: "directory", Title: "directory", Type: "string"},
+					{ID: "outdegree", Title: "outdegree", Type: "integer"},
+					{ID: "indegree", Title: "indegree", Type: "integer"},
+				},
+			},
+		},
+	}
+	for _, n := range wiki.nodes() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    n,
+			Label: wiki.label(n),
+			Values: []gexfValue{
+				{For: "directory", Value: wiki.directory(n)},
+				{For: "outdegree", Value: out[n]},
+				{For: "indegree", Value: in[n]},
+			},
+		})
+	}
+	for i, e := range wiki.Edges() {
+		weight := 1
+		if edge, ok := wiki.edges[e]; ok && edge.Weight > 0 {
+			weight = edge.Weight
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{ID: i, Source: e[0], Target: e[1], Weight: weight})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type jgf struct {
+	Graph jgfGraph `json:"graph"`
+}
+
+type jgfGraph struct {
+	Directed bool      `json:"directed"`
+	Nodes    []jgfNode `json:"nodes"`
+	Edges    []jgfEdge `json:"edges"`
+}
+
+type jgfNode struct {
+	ID       string          `json:"id"`
+	Label    string          `json:"label"`
+	Metadata jgfNodeMetadata `json:"metadata"`
+}
+
+type jgfNodeMetadata struct {
+	Directory string   `json:"directory,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	External  bool     `json:"external,omitempty"`
+	Asset     bool     `json:"asset,omitempty"`
+	Words     int      `json:"words,omitempty"`
+	Headings  []string `json:"headings,omitempty"`
+}
+
+type jgfEdge struct {
+	Source   string          `json:"source"`
+	Target   string          `json:"target"`
+	Directed bool            `json:"directed"`
+	Metadata jgfEdgeMetadata `json:"metadata"`
+}
+
+type jgfEdgeMetadata struct {
+	Weight int    `json:"weight"`
+	Label  string `json:"label,omitempty"`
+	Lines  []int  `json:"lines,omitempty"`
+}
+
+// JGF writes the graph to w in the JSON Graph Format, including all nodes and
+// links sorted by their path. Each node is identified by its path and has the
+// number of words and the headings of its files as metadata, and each edge
+// has the number of its links, the first description and the lines of the
+// links in the source file as metadata.
+func (wiki *Wiki) JGF(w io.Writer) error {
+	doc := jgf{Graph: jgfGraph{
+		Directed: true,
+		Nodes:    make([]jgfNode, 0),
+		Edges:    make([]jgfEdge, 0),
+	}}
+	for _, n := range wiki.nodes() {
+		meta := jgfNodeMetadata{
+			Directory: wiki.directory(n),
+			Title:     wiki.titles[n],
+			External:  wiki.externals[n],
+			Asset:     wiki.assets[n],
+		}
+		if m, ok := wiki.meta[n]; ok {
+			meta.Words = m.Words
+			meta.Headings = m.Headings
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, jgfNode{ID: n, Label: wiki.label(n), Metadata: meta})
+	}
+	for _, e := range wiki.Edges() {
+		meta := jgfEdgeMetadata{Weight: 1}
+		if edge, ok := wiki.edges[e]; ok {
+			if edge.Weight > 0 {
+				meta.Weight = edge.Weight
+			}
+			meta.Label = edge.Label
+			meta.Lines = edge.Lines
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, jgfEdge{Source: e[0], Target: e[1], Directed: true, Metadata: meta})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// EdgeList writes every link of the graph to w as a line with its source and
+// target separated by a tab, sorted by source and then by target, without a
+// header. Like Dot, only the links of nodes with at least level links are
+// written, and links created from fewer than wiki.MinWeight links are left
+// out if wiki.MinWeight > 1.
+func (wiki *Wiki) EdgeList(level int, w io.Writer) error {
+	if wiki.MinWeight > 1 {
+		c := wiki.clone()
+		c.removeWeakLinks(wiki.MinWeight)
+		c.MinWeight = 0
+		return c.EdgeList(level, w)
+	}
+
+	out, in := wiki.degrees()
+	for _, e := range wiki.Edges() {
+		if wiki.degree(e[0], out, in) < level {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", e[0], e[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefaultDotTemplate is the template used by DotTemplate if none is given. It
+// draws each node by its label and each edge with its label, if any.
+const DefaultDotTemplate = `digraph {
+{{- range .Nodes}}
+	{{quote .ID}} [label={{quote .Label}}];
+{{- end}}
+{{- range .Edges}}
+	{{quote .Source}} -> {{quote .Target}}{{if .Label}} [label={{quote .Label}}]{{end}};
+{{- end}}
+}
+`
+
+// TemplateData is passed to the template of DotTemplate, holding all nodes
+// and links sorted by their path.
+type TemplateData struct {
+	Nodes []TemplateNode
+	Edges []TemplateEdge
+}
+
+// TemplateNode holds the attributes of a node passed to the template of
+// DotTemplate.
+type TemplateNode struct {
+	ID        string
+	Label     string
+	Directory string
+	Title     string
+	External  bool
+	Asset     bool
+	Out       int
+	In        int
+}
+
+// TemplateEdge holds the attributes of an edge passed to the template of
+// DotTemplate.
+type TemplateEdge struct {
+	Source string
+	Target string
+	Label  string
+	Weight int
+	Cross  bool
+}
+
+// NewDotTemplate parses text as the template named name for DotTemplate. The
+// function quote is available in text to quote and escape a string for dot,
+// e.g. {{quote .Label}}.
+func NewDotTemplate(name, text string) (*template.Template, error) {
+	funcs := template.FuncMap{"quote": func(s string) string { return string(quote(s)) }}
+	return template.New(name).Funcs(funcs).Parse(text)
+}
+
+// DotTemplate writes the dot source produced by executing tmpl on the
+// TemplateData of the graph to w, including all nodes and links regardless
+// of the options for Dot. If tmpl is nil DefaultDotTemplate is used.
+func (wiki *Wiki) DotTemplate(tmpl *template.Template, w io.Writer) error {
+	if tmpl == nil {
+		var err error
+		tmpl, err = NewDotTemplate("dot", DefaultDotTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
+	out, in := wiki.degrees()
+	data := TemplateData{Nodes: make([]TemplateNode, 0), Edges: make([]TemplateEdge, 0)}
+	for _, n := range wiki.nodes() {
+		data.Nodes = append(data.Nodes, TemplateNode{
+			ID:        n,
+			Label:     wiki.label(n),
+			Directory: wiki.directory(n),
+			Title:     wiki.titles[n],
+			External:  wiki.externals[n],
+			Asset:     wiki.assets[n],
+			Out:       out[n],
+			In:        in[n],
+		})
+	}
+	for _, e := range wiki.Edges() {
+		edge := TemplateEdge{Source: e[0], Target: e[1], Weight: 1}
+		if ed, ok := wiki.edges[e]; ok {
+			if ed.Weight > 0 {
+				edge.Weight = ed.Weight
+			}
+			edge.Label = ed.Label
+			edge.Cross = ed.Cross
+		}
+		data.Edges = append(data.Edges, edge)
+	}
+	return tmpl.Execute(w, data)
+}
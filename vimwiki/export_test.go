@@ -0,0 +1,204 @@
+package vimwiki
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGEXF(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"a/x & y.wiki": {"index.wiki"},
+			"index.wiki":   {"a/x & y.wiki"},
+		},
+		edges: map[[2]string]*Edge{{"a/x & y.wiki", "index.wiki"}: {Weight: 2}},
+	}
+
+	var buf bytes.Buffer
+	if err := wiki.GEXF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	// the document is valid XML
+	var doc struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>nodes>node"`
+		Edges []struct {
+			Weight int `xml:"weight,attr"`
+		} `xml:"graph>edges>edge"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid XML, got %v:\n%v", err, out)
+	}
+	if len(doc.Nodes) != 2 || doc.Nodes[0].ID != "a/x & y.wiki" {
+		t.Errorf("Expected two nodes, got %+v", doc.Nodes)
+	}
+	if len(doc.Edges) != 2 || doc.Edges[0].Weight != 2 {
+		t.Errorf("Expected two edges, the first with weight 2, got %+v", doc.Edges)
+	}
+
+	for _, exp := range []string{
+		`<gexf xmlns="http://gexf.net/1.3" version="1.3">`,
+		`<node id="a/x &amp; y.wiki" label="a/x &amp; y.wiki">`,
+		`<attvalue for="directory" value="a">`,
+		`<attvalue for="outdegree" value="1">`,
+		`<attvalue for="indegree" value="1">`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+}
+
+func TestJGF(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki": {"b.wiki", "a.wiki"},
+			"a.wiki":     {},
+		},
+		edges: map[[2]string]*Edge{
+			{"index.wiki", "a.wiki"}: {Weight: 2, Label: "first", Lines: []int{1, 3}},
+		},
+		titles: map[string]string{"index.wiki": "Index"},
+		meta:   map[string]*meta{"index.wiki": {Words: 3, Headings: []string{"Index", "Notes"}}},
+	}
+
+	var buf bytes.Buffer
+	if err := wiki.JGF(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc jgf
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%v", err, buf.String())
+	}
+	if !doc.Graph.Directed {
+		t.Errorf("Expected a directed graph")
+	}
+
+	var ids []string
+	for _, n := range doc.Graph.Nodes {
+		ids = append(ids, n.ID)
+	}
+	if got := fmt.Sprint(ids); got != "[a.wiki b.wiki index.wiki]" {
+		t.Errorf("Expected sorted nodes, got %v", got)
+	}
+	if got := doc.Graph.Nodes[2].Metadata.Title; got != "Index" {
+		t.Errorf("Expected title Index, got %v", got)
+	}
+	if m := doc.Graph.Nodes[2].Metadata; m.Words != 3 || fmt.Sprint(m.Headings) != "[Index Notes]" {
+		t.Errorf("Expected 3 words and headings [Index Notes], got %+v", m)
+	}
+
+	exp := "[{index.wiki a.wiki true {2 first [1 3]}} {index.wiki b.wiki true {1  []}}]"
+	if got := fmt.Sprint(doc.Graph.Edges); got != exp {
+		t.Errorf("Expected edges %v, got %v", exp, got)
+	}
+}
+
+func TestDotTemplate(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki": {`a/"x".wiki`, "https://a.com"},
+			`a/"x".wiki`: {"index.wiki"},
+		},
+		edges: map[[2]string]*Edge{
+			{"index.wiki", `a/"x".wiki`}: {Weight: 2, Label: "see x"},
+		},
+		externals: map[string]bool{"https://a.com": true},
+		Aliases:   map[string]string{"index.wiki": "Home"},
+	}
+
+	var buf bytes.Buffer
+	if err := wiki.DotTemplate(nil, &buf); err != nil {
+		t.Fatal(err)
+	}
+	exp := "digraph {\n" +
+		"\t\"a/\\\"x\\\".wiki\" [label=\"a/\\\"x\\\".wiki\"];\n" +
+		"\t\"https://a.com\" [label=\"https://a.com\"];\n" +
+		"\t\"index.wiki\" [label=\"Home\"];\n" +
+		"\t\"a/\\\"x\\\".wiki\" -> \"index.wiki\";\n" +
+		"\t\"index.wiki\" -> \"a/\\\"x\\\".wiki\" [label=\"see x\"];\n" +
+		"\t\"index.wiki\" -> \"https://a.com\";\n" +
+		"}\n"
+	if got := buf.String(); got != exp {
+		t.Errorf("Expected default output:\n%v\ngot:\n%v", exp, got)
+	}
+
+	tmpl, err := NewDotTemplate("custom", "graph {\n"+
+		"{{- range .Nodes}}{{if not .External}}\n\t{{quote .ID}} [dir={{quote .Directory}}, degree={{.Out}}];{{end}}{{end}}\n"+
+		"{{- range .Edges}}\n\t{{quote .Source}} -- {{quote .Target}} [penwidth={{.Weight}}];{{end}}\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := wiki.DotTemplate(tmpl, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{
+		"\t\"index.wiki\" [dir=\".\", degree=2];",
+		"\t\"a/\\\"x\\\".wiki\" [dir=\"a\", degree=1];",
+		"\t\"index.wiki\" -- \"a/\\\"x\\\".wiki\" [penwidth=2];",
+		"\t\"index.wiki\" -- \"https://a.com\" [penwidth=1];",
+	} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "[dir=\"\"") {
+		t.Errorf("Expected external nodes to be skipped, got:\n%v", buf.String())
+	}
+}
+
+func TestEdgeList(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki": {"b.wiki", "a.wiki", "https://a.com"},
+			"a.wiki":     {"index.wiki"},
+			"b.wiki":     {},
+			"c.wiki":     {"d.wiki"},
+		},
+		edges: map[[2]string]*Edge{{"index.wiki", "a.wiki"}: {Weight: 3}},
+	}
+
+	var buf bytes.Buffer
+	if err := wiki.EdgeList(0, &buf); err != nil {
+		t.Fatal(err)
+	}
+	exp := "a.wiki\tindex.wiki\n" +
+		"c.wiki\td.wiki\n" +
+		"index.wiki\ta.wiki\n" +
+		"index.wiki\tb.wiki\n" +
+		"index.wiki\thttps://a.com\n"
+	if got := buf.String(); got != exp {
+		t.Errorf("Expected edge list:\n%v\ngot:\n%v", exp, got)
+	}
+
+	// only the links of nodes with at least two links
+	buf.Reset()
+	if err := wiki.EdgeList(2, &buf); err != nil {
+		t.Fatal(err)
+	}
+	exp = "a.wiki\tindex.wiki\n" +
+		"index.wiki\ta.wiki\n" +
+		"index.wiki\tb.wiki\n" +
+		"index.wiki\thttps://a.com\n"
+	if got := buf.String(); got != exp {
+		t.Errorf("Expected edge list:\n%v\ngot:\n%v", exp, got)
+	}
+
+	buf.Reset()
+	wiki.MinWeight = 2
+	if err := wiki.EdgeList(0, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "index.wiki\ta.wiki\n" {
+		t.Errorf("Expected only the edge with weight 3, got:\n%v", got)
+	}
+}
@@ -0,0 +1,484 @@
+package vimwiki
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dateLayout is the layout of the names of diary entries, e.g. 2006-01-02.wiki
+const dateLayout = "2006-01-02"
+
+// nodes returns the sorted names of all files in wiki.graph, including those
+// that are only present as the target of a link.
+func (wiki *Wiki) nodes() []string {
+	seen := make(map[string]bool)
+	for k, val := range wiki.graph {
+		seen[k] = true
+		for _, v := range val {
+			seen[v] = true
+		}
+	}
+
+	nodes := make([]string, 0, len(seen))
+	for n := range seen {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Graph returns a copy of the graph, mapping each file to the files it links
+// to. Modifying the copy does not affect the wiki.
+func (wiki *Wiki) Graph() map[string][]string {
+	graph := make(map[string][]string, len(wiki.graph))
+	for k, val := range wiki.graph {
+		graph[k] = append([]string{}, val...)
+	}
+	return graph
+}
+
+// Nodes returns the sorted names of all nodes in the graph, including files
+// that are only present as the target of a link.
+func (wiki *Wiki) Nodes() []string {
+	return wiki.nodes()
+}
+
+// Edges returns all links in the graph as pairs of source and target, sorted
+// by source and then by target.
+func (wiki *Wiki) Edges() [][2]string {
+	var edges [][2]string
+	for k, val := range wiki.graph {
+		for _, v := range val {
+			edges = append(edges, [2]string{k, v})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+	return edges
+}
+
+// Edge returns a copy of the properties of the connection from source to
+// target, e.g. the lines of source containing the links, or false if there is
+// no such connection.
+func (wiki *Wiki) Edge(source, target string) (Edge, bool) {
+	e, ok := wiki.edges[[2]string{source, target}]
+	if !ok {
+		return Edge{}, false
+	}
+	edge := *e
+	edge.Lines = append([]int{}, e.Lines...)
+	return edge, true
+}
+
+// OutLinks returns the sorted nodes that node links to.
+func (wiki *Wiki) OutLinks(node string) []string {
+	links := append([]string{}, wiki.graph[node]...)
+	sort.Strings(links)
+	return links
+}
+
+// InLinks returns the sorted nodes linking to node. The links of all nodes
+// are collected on the first call and reused until the graph changes.
+func (wiki *Wiki) InLinks(node string) []string {
+	if wiki.inLinks == nil || wiki.dirty {
+		wiki.inLinks = wiki.reverse()
+		for _, val := range wiki.inLinks {
+			sort.Strings(val)
+		}
+		wiki.dirty = false
+	}
+	return append([]string{}, wiki.inLinks[node]...)
+}
+
+// reverse returns the graph with all links reversed, i.e. mapping each file
+// to the files that link to it.
+func (wiki *Wiki) reverse() map[string][]string {
+	rev := make(map[string][]string)
+	for k, val := range wiki.graph {
+		for _, v := range val {
+			rev[v] = append(rev[v], k)
+		}
+	}
+	return rev
+}
+
+// degrees returns the number of outgoing and incoming links of each node.
+func (wiki *Wiki) degrees() (out, in map[string]int) {
+	out = make(map[string]int)
+	in = make(map[string]int)
+	for k, val := range wiki.graph {
+		out[k] += len(val)
+		for _, v := range val {
+			in[v]++
+		}
+	}
+	return out, in
+}
+
+// removeNode removes the node and all links to and from it from the graph.
+func (wiki *Wiki) removeNode(node string) {
+	wiki.dirty = true
+	delete(wiki.graph, node)
+	delete(wiki.present, node)
+	for k, val := range wiki.graph {
+		links := val[:0]
+		for _, v := range val {
+			if v != node {
+				links = append(links, v)
+			}
+		}
+		wiki.graph[k] = links
+	}
+	for e := range wiki.edges {
+		if e[0] == node || e[1] == node {
+			delete(wiki.edges, e)
+		}
+	}
+}
+
+// weight returns the number of links from source to target, which is one for
+// links without recorded properties.
+func (wiki *Wiki) weight(source, target string) int {
+	if e, ok := wiki.edges[[2]string{source, target}]; ok && e.Weight > 0 {
+		return e.Weight
+	}
+	return 1
+}
+
+// removeWeakLinks removes all links from the graph that occur less than min
+// times in their source file.
+func (wiki *Wiki) removeWeakLinks(min int) {
+	wiki.dirty = true
+	for k, val := range wiki.graph {
+		links := make([]string, 0, len(val))
+		for _, v := range val {
+			if wiki.weight(k, v) >= min {
+				links = append(links, v)
+			}
+		}
+		wiki.graph[k] = links
+	}
+}
+
+// Exclude removes the given nodes, after renaming, and all links to and from
+// them from the graph, e.g. a hub such as index.wiki that dominates the
+// layout. An error is returned if any of the nodes is not present in the
+// graph, in which case the graph is left unchanged.
+func (wiki *Wiki) Exclude(nodes ...string) error {
+	present := make(map[string]bool)
+	for _, n := range wiki.nodes() {
+		present[n] = true
+	}
+	for _, n := range nodes {
+		if !present[n] {
+			return fmt.Errorf("cannot exclude %v: node not in graph", n)
+		}
+	}
+	for _, n := range nodes {
+		wiki.removeNode(n)
+	}
+	return nil
+}
+
+// FollowRedirects removes every note whose file only redirects to another
+// note, i.e. its text is a single link, and rewires the links to the redirect
+// to its target instead. Chains of redirects are followed to their final
+// target, while redirects that form a cycle are kept.
+func (wiki *Wiki) FollowRedirects() {
+	target := func(n string) (string, bool) {
+		if !wiki.redirects[n] || len(wiki.graph[n]) != 1 || wiki.graph[n][0] == n {
+			return "", false
+		}
+		return wiki.graph[n][0], true
+	}
+
+	// resolve the final target of each redirect
+	final := make(map[string]string)
+	for n := range wiki.redirects {
+		seen := map[string]bool{n: true}
+		t, ok := target(n)
+		for ok {
+			if seen[t] {
+				break
+			}
+			seen[t] = true
+			final[n] = t
+			t, ok = target(t)
+		}
+		if ok {
+			// part of a cycle
+			delete(final, n)
+		}
+	}
+
+	wiki.dirty = true
+	for k, val := range wiki.graph {
+		if _, ok := final[k]; ok {
+			continue
+		}
+		links := make([]string, 0, len(val))
+		for _, v := range val {
+			t, ok := final[v]
+			if !ok {
+				if unique(v, links) {
+					links = append(links, v)
+				}
+				continue
+			}
+			if unique(t, links) {
+				links = append(links, t)
+			}
+			from, to := wiki.edges[[2]string{k, v}], wiki.edges[[2]string{k, t}]
+			if from == nil {
+				continue
+			}
+			if to == nil {
+				wiki.edges[[2]string{k, t}] = from
+				continue
+			}
+			to.Weight += from.Weight
+			to.Task = to.Task || from.Task
+			to.Embed = to.Embed || from.Embed
+			to.Lines = append(to.Lines, from.Lines...)
+			sort.Ints(to.Lines)
+		}
+		wiki.graph[k] = links
+	}
+	for n := range final {
+		wiki.removeNode(n)
+	}
+}
+
+// topLevel returns the top-level directory of the file of the node of path,
+// i.e. "." for files in the wiki's directory, or an empty string for external
+// websites and pages in other wikis.
+func (wiki *Wiki) topLevel(p string) string {
+	dir := wiki.directory(p)
+	if dir == "" {
+		return ""
+	}
+	return strings.SplitN(dir, "/", 2)[0]
+}
+
+// CrossDirOnly removes all links between files in the same top-level
+// directory, after renaming, keeping only the links between directories,
+// e.g. a/x.wiki to b/y.wiki, and to external websites or other wikis.
+func (wiki *Wiki) CrossDirOnly() {
+	wiki.dirty = true
+	for k, val := range wiki.graph {
+		links := val[:0]
+		for _, v := range val {
+			if wiki.topLevel(k) != wiki.topLevel(v) {
+				links = append(links, v)
+			} else {
+				delete(wiki.edges, [2]string{k, v})
+			}
+		}
+		wiki.graph[k] = links
+	}
+}
+
+// clone returns a copy of wiki whose graph can be modified without affecting
+// wiki. Any other state is shared.
+func (wiki *Wiki) clone() *Wiki {
+	c := *wiki
+	c.graph = wiki.Graph()
+	c.edges = make(map[[2]string]*Edge, len(wiki.edges))
+	for e, v := range wiki.edges {
+		c.edges[e] = v
+	}
+	c.present = make(map[string]bool, len(wiki.present))
+	for n := range wiki.present {
+		c.present[n] = true
+	}
+	return &c
+}
+
+// Only returns a copy of wiki with just the given nodes and the links between
+// them, i.e. the subgraph induced by nodes, e.g. to draw a curated selection
+// of notes. The nodes that are not present in the graph are returned too.
+func (wiki *Wiki) Only(nodes ...string) (*Wiki, []string) {
+	keep := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		keep[n] = true
+	}
+
+	c := *wiki
+	c.graph = make(map[string][]string)
+	c.edges = make(map[[2]string]*Edge)
+	c.present = make(map[string]bool)
+	c.inLinks = nil
+
+	present := make(map[string]bool)
+	for _, n := range wiki.nodes() {
+		present[n] = true
+	}
+
+	var unknown []string
+	for _, n := range nodes {
+		if !present[n] {
+			unknown = append(unknown, n)
+			continue
+		}
+		links := make([]string, 0)
+		for _, v := range wiki.graph[n] {
+			if !keep[v] {
+				continue
+			}
+			links = append(links, v)
+			if edge, ok := wiki.edges[[2]string{n, v}]; ok {
+				c.edges[[2]string{n, v}] = edge
+			}
+		}
+		c.graph[n] = links
+		if wiki.present[n] {
+			c.present[n] = true
+		}
+	}
+	return &c, unknown
+}
+
+// PruneLeaves returns a copy of wiki from which, in every round, all nodes
+// with at most one link to or from other nodes are removed. This leaves the
+// densely connected core of the graph after several rounds.
+func (wiki *Wiki) PruneLeaves(rounds int) *Wiki {
+	c := wiki.clone()
+	for i := 0; i < rounds; i++ {
+		out, in := c.degrees()
+		for _, n := range c.nodes() {
+			if out[n]+in[n] <= 1 {
+				c.removeNode(n)
+			}
+		}
+	}
+	return c
+}
+
+// CollapseToDirs returns a copy of wiki in which the nodes of all files are
+// replaced by a single node for each directory, see DirGraph. The edges
+// between directories are labelled by their number of links, which Dot draws
+// if wiki.EdgeLabels is set.
+func (wiki *Wiki) CollapseToDirs() *Wiki {
+	c := *wiki
+	c.graph = make(map[string][]string)
+	c.edges = make(map[[2]string]*Edge)
+	c.present = make(map[string]bool)
+	c.names = make(map[string]string)
+	c.titles = make(map[string]string)
+	c.inLinks = nil
+	for from, targets := range wiki.DirGraph() {
+		links := make([]string, 0, len(targets))
+		for to, n := range targets {
+			links = append(links, to)
+			c.edges[[2]string{from, to}] = &Edge{Label: fmt.Sprint(n), Weight: n}
+		}
+		sort.Strings(links)
+		c.graph[from] = links
+		c.present[from] = true
+	}
+	return &c
+}
+
+// Tree returns a copy of wiki with the spanning tree of all nodes reachable
+// from root, found by a breadth first search along the links in their order
+// of appearance, e.g. to draw the table of contents of index.wiki as an
+// outline. Any other links between these nodes are kept as cross edges, which
+// Dot draws dashed without affecting the layout. An error is returned if root
+// is not present in the graph.
+func (wiki *Wiki) Tree(root string) (*Wiki, error) {
+	if unique(root, wiki.nodes()) {
+		return nil, fmt.Errorf("cannot draw tree of %v: node not in graph", root)
+	}
+
+	c := *wiki
+	c.graph = make(map[string][]string)
+	c.edges = make(map[[2]string]*Edge)
+	c.present = map[string]bool{root: true}
+	c.inLinks = nil
+
+	queue := []string{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		c.graph[n] = make([]string, 0)
+		for _, v := range wiki.graph[n] {
+			if v == n {
+				continue
+			}
+			e := [2]string{n, v}
+			if !c.present[v] {
+				c.present[v] = true
+				queue = append(queue, v)
+				if edge, ok := wiki.edges[e]; ok {
+					c.edges[e] = edge
+				}
+			} else {
+				edge := Edge{Weight: 1}
+				if orig, ok := wiki.edges[e]; ok {
+					edge = *orig
+				}
+				edge.Cross = true
+				c.edges[e] = &edge
+			}
+			c.graph[n] = append(c.graph[n], v)
+		}
+	}
+	return &c, nil
+}
+
+// dateOf returns the date in the name of a diary entry, e.g.
+// diary/2006-01-02.wiki, or false if the name is not a date.
+func dateOf(path string) (time.Time, bool) {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	date, err := time.Parse(dateLayout, name)
+	return date, err == nil
+}
+
+// FilterByDate removes all diary entries, i.e. files named by their date as
+// 2006-01-02.wiki, dated before since or after until, unless they are directly
+// connected to an entry within the range. A zero since or until leaves the
+// range unbounded on that side. Files without a date are always kept.
+func (wiki *Wiki) FilterByDate(since, until time.Time) {
+	inRange := func(node string) (dated, ok bool) {
+		date, dated := dateOf(node)
+		if !dated {
+			return false, true
+		}
+		if !since.IsZero() && date.Before(since) {
+			return true, false
+		}
+		if !until.IsZero() && date.After(until) {
+			return true, false
+		}
+		return true, true
+	}
+
+	// keep entries within the range and their direct neighbours
+	keep := make(map[string]bool)
+	for k, val := range wiki.graph {
+		for _, v := range val {
+			dk, ok := inRange(k)
+			dv, ov := inRange(v)
+			if dk && ok {
+				keep[v] = true
+			}
+			if dv && ov {
+				keep[k] = true
+			}
+		}
+	}
+
+	for _, n := range wiki.nodes() {
+		if _, ok := inRange(n); !ok && !keep[n] {
+			wiki.removeNode(n)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package vimwiki
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/dot"
+)
+
+func TestDegrees(t *testing.T) {
+	wiki := Wiki{graph: map[string][]string{
+		"a.wiki": {"b.wiki", "c.wiki"},
+		"b.wiki": {"c.wiki"},
+	}}
+
+	if nodes := fmt.Sprint(wiki.nodes()); nodes != "[a.wiki b.wiki c.wiki]" {
+		t.Errorf("Expected all nodes, got %v", nodes)
+	}
+
+	out, in := wiki.degrees()
+	exp := map[string][2]int{"a.wiki": {2, 0}, "b.wiki": {1, 1}, "c.wiki": {0, 2}}
+	for n, d := range exp {
+		if out[n] != d[0] || in[n] != d[1] {
+			t.Errorf("Expected degrees %v for %v, got %v, %v", d, n, out[n], in[n])
+		}
+	}
+
+	if rev := fmt.Sprint(wiki.reverse()["c.wiki"]); rev != "[a.wiki b.wiki]" && rev != "[b.wiki a.wiki]" {
+		t.Errorf("Expected c.wiki to be linked from a.wiki and b.wiki, got %v", rev)
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	wiki := Wiki{graph: map[string][]string{
+		"b.wiki": {"c.wiki", "a.wiki"},
+		"a.wiki": {"b.wiki"},
+	}}
+
+	graph := wiki.Graph()
+	graph["a.wiki"][0] = "changed.wiki"
+	graph["new.wiki"] = nil
+	if len(wiki.graph) != 2 || wiki.graph["a.wiki"][0] != "b.wiki" {
+		t.Errorf("Expected graph to be unchanged, got %v", wiki.graph)
+	}
+
+	if nodes := fmt.Sprint(wiki.Nodes()); nodes != "[a.wiki b.wiki c.wiki]" {
+		t.Errorf("Expected all nodes, got %v", nodes)
+	}
+
+	exp := "[[a.wiki b.wiki] [b.wiki a.wiki] [b.wiki c.wiki]]"
+	if edges := fmt.Sprint(wiki.Edges()); edges != exp {
+		t.Errorf("Expected edges %v, got %v", exp, edges)
+	}
+}
+
+func TestPruneLeaves(t *testing.T) {
+	wiki := Wiki{graph: map[string][]string{
+		"a.wiki": {"b.wiki"},
+		"b.wiki": {"c.wiki"},
+		"c.wiki": {"a.wiki", "d.wiki"},
+		"d.wiki": {"e.wiki"},
+		"f.wiki": {},
+	}}
+
+	for rounds, exp := range map[int]string{
+		0: "[a.wiki b.wiki c.wiki d.wiki e.wiki f.wiki]",
+		1: "[a.wiki b.wiki c.wiki d.wiki]",
+		2: "[a.wiki b.wiki c.wiki]",
+		3: "[a.wiki b.wiki c.wiki]",
+	} {
+		if nodes := fmt.Sprint(wiki.PruneLeaves(rounds).nodes()); nodes != exp {
+			t.Errorf("Expected %v after %v rounds, got %v", exp, rounds, nodes)
+		}
+	}
+	if len(wiki.graph) != 5 || len(wiki.graph["c.wiki"]) != 2 {
+		t.Errorf("Expected graph to be unchanged, got %v", wiki.graph)
+	}
+}
+
+func TestFilterByDate(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki":            {"diary/2023-01-01.wiki", "diary/2022-06-01.wiki"},
+			"diary/2023-01-01.wiki": {"diary/2022-12-31.wiki"},
+			"diary/2022-12-31.wiki": {},
+			"diary/2022-06-01.wiki": {"other.wiki"},
+			"diary/2024-01-01.wiki": {"index.wiki"},
+			"other.wiki":            {},
+		},
+		edges: make(map[[2]string]*Edge),
+	}
+
+	since := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	wiki.FilterByDate(since, until)
+
+	// 2022-12-31 is kept as a neighbour of 2023-01-01, files without a
+	// date are never removed
+	exp := "[diary/2022-12-31.wiki diary/2023-01-01.wiki index.wiki other.wiki]"
+	if nodes := fmt.Sprint(wiki.nodes()); nodes != exp {
+		t.Errorf("Expected nodes %v, got %v", exp, nodes)
+	}
+	if links := fmt.Sprint(wiki.graph["index.wiki"]); links != "[diary/2023-01-01.wiki]" {
+		t.Errorf("Expected links to removed entries to be dropped, got %v", links)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki": {"a.wiki", "b.wiki"},
+			"a.wiki":     {"index.wiki", "b.wiki"},
+			"b.wiki":     {"c.wiki"},
+		},
+		edges: map[[2]string]*Edge{
+			{"index.wiki", "a.wiki"}: {Weight: 1},
+			{"a.wiki", "b.wiki"}:     {Weight: 1},
+		},
+	}
+
+	if err := wiki.Exclude("index.wiki", "c.wiki"); err != nil {
+		t.Fatal(err)
+	}
+	if nodes := fmt.Sprint(wiki.nodes()); nodes != "[a.wiki b.wiki]" {
+		t.Errorf("Expected neighbours to remain, got %v", nodes)
+	}
+	if edges := fmt.Sprint(wiki.Edges()); edges != "[[a.wiki b.wiki]]" {
+		t.Errorf("Expected only the edge between the neighbours, got %v", edges)
+	}
+	if _, ok := wiki.Edge("index.wiki", "a.wiki"); ok {
+		t.Errorf("Expected properties of removed edges to be removed")
+	}
+
+	if err := wiki.Exclude("a.wiki", "missing.wiki"); err == nil {
+		t.Errorf("Expected an error when excluding a missing node")
+	}
+	if nodes := fmt.Sprint(wiki.nodes()); nodes != "[a.wiki b.wiki]" {
+		t.Errorf("Expected graph to be unchanged after an error, got %v", nodes)
+	}
+}
+
+func TestFollowRedirects(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki": "[[old]]\n[[note]]\n[[older]]",
+		"old.wiki":   "= Old =\n\n[[note]]\n",
+		"older.wiki": "[[old]]",
+		"note.wiki":  "the note, see [[index]]",
+		"a.wiki":     "[[b]]",
+		"b.wiki":     "[[a]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+	wiki.FollowRedirects()
+
+	// redirects that form a cycle are kept
+	exp := "[[a.wiki b.wiki] [b.wiki a.wiki] [index.wiki note.wiki] [note.wiki index.wiki]]"
+	if got := fmt.Sprint(wiki.Edges()); got != exp {
+		t.Errorf("Expected edges %v, got %v", exp, got)
+	}
+	edge, _ := wiki.Edge("index.wiki", "note.wiki")
+	if edge.Weight != 3 || fmt.Sprint(edge.Lines) != "[1 2 3]" {
+		t.Errorf("Expected links to redirects to be merged, got %+v", edge)
+	}
+}
+
+func TestCrossDirOnly(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki":   {"a/x.wiki", "other.wiki", "https://a.com"},
+			"a/x.wiki":     {"a/b/y.wiki", "b/z.wiki"},
+			"a/b/y.wiki":   {"a/x.wiki", "index.wiki"},
+			"b/z.wiki":     {},
+			"other.wiki":   {"index.wiki"},
+			"b/other.wiki": {"b/z.wiki"},
+		},
+		edges: map[[2]string]*Edge{
+			{"a/x.wiki", "a/b/y.wiki"}: {Weight: 1},
+			{"a/x.wiki", "b/z.wiki"}:   {Weight: 1},
+		},
+		externals: map[string]bool{"https://a.com": true},
+	}
+	wiki.CrossDirOnly()
+
+	exp := "[[a/b/y.wiki index.wiki] [a/x.wiki b/z.wiki] [index.wiki a/x.wiki] [index.wiki https://a.com]]"
+	if got := fmt.Sprint(wiki.Edges()); got != exp {
+		t.Errorf("Expected edges %v, got %v", exp, got)
+	}
+	if _, ok := wiki.Edge("a/x.wiki", "a/b/y.wiki"); ok {
+		t.Errorf("Expected properties of removed edges to be removed")
+	}
+	if _, ok := wiki.Edge("a/x.wiki", "b/z.wiki"); !ok {
+		t.Errorf("Expected properties of kept edges to remain")
+	}
+}
+
+func TestNeighbours(t *testing.T) {
+	wiki := Wiki{graph: map[string][]string{
+		"index.wiki": {"c.wiki", "a.wiki"},
+		"b.wiki":     {"a.wiki"},
+		"a.wiki":     {},
+	}}
+
+	if got := fmt.Sprint(wiki.OutLinks("index.wiki")); got != "[a.wiki c.wiki]" {
+		t.Errorf("Expected sorted outgoing links, got %v", got)
+	}
+	if got := fmt.Sprint(wiki.InLinks("a.wiki")); got != "[b.wiki index.wiki]" {
+		t.Errorf("Expected sorted incoming links, got %v", got)
+	}
+	if got := wiki.InLinks("index.wiki"); len(got) != 0 {
+		t.Errorf("Expected no incoming links, got %v", got)
+	}
+
+	// the copies do not affect the graph
+	wiki.OutLinks("index.wiki")[0] = "x.wiki"
+	wiki.InLinks("a.wiki")[0] = "x.wiki"
+	if got := fmt.Sprint(wiki.InLinks("a.wiki")); got != "[b.wiki index.wiki]" {
+		t.Errorf("Expected incoming links to be unchanged, got %v", got)
+	}
+
+	// changes to the graph are reflected
+	wiki.Insert("c.wiki", "index.wiki")
+	if got := fmt.Sprint(wiki.InLinks("index.wiki")); got != "[c.wiki]" {
+		t.Errorf("Expected incoming links after inserting a link, got %v", got)
+	}
+	wiki.removeNode("b.wiki")
+	if got := fmt.Sprint(wiki.InLinks("a.wiki")); got != "[index.wiki]" {
+		t.Errorf("Expected incoming links after removing a node, got %v", got)
+	}
+}
+
+func TestTree(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki": {"a.wiki", "b.wiki"},
+			"a.wiki":     {"a1.wiki", "b.wiki", "a.wiki"},
+			"b.wiki":     {"b1.wiki", "index.wiki"},
+			"b1.wiki":    {"a1.wiki"},
+			"other.wiki": {"index.wiki"},
+		},
+		edges: map[[2]string]*Edge{
+			{"a.wiki", "b.wiki"}: {Weight: 2, Label: "see b"},
+		},
+	}
+
+	tree, err := wiki.Tree("index.wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nodes not reachable from the root and self-loops are left out
+	exp := "[[a.wiki a1.wiki] [a.wiki b.wiki] [b.wiki b1.wiki] [b.wiki index.wiki] " +
+		"[b1.wiki a1.wiki] [index.wiki a.wiki] [index.wiki b.wiki]]"
+	if got := fmt.Sprint(tree.Edges()); got != exp {
+		t.Errorf("Expected edges %v, got %v", exp, got)
+	}
+	cross := make([]string, 0)
+	for _, e := range tree.Edges() {
+		if edge, ok := tree.Edge(e[0], e[1]); ok && edge.Cross {
+			cross = append(cross, e[0]+"->"+e[1])
+		}
+	}
+	if got := fmt.Sprint(cross); got != "[a.wiki->b.wiki b.wiki->index.wiki b1.wiki->a1.wiki]" {
+		t.Errorf("Expected the links outside the tree as cross edges, got %v", got)
+	}
+	if edge, _ := tree.Edge("a.wiki", "b.wiki"); edge.Label != "see b" {
+		t.Errorf("Expected cross edges to keep their properties, got %+v", edge)
+	}
+	if edge, _ := wiki.Edge("a.wiki", "b.wiki"); edge.Cross {
+		t.Errorf("Expected the original edges to be unchanged")
+	}
+
+	out := tree.Dot(0, dot.Directed).String()
+	if got := strings.Count(out, `[constraint="false",style="dashed"]`); got != 3 {
+		t.Errorf("Expected three dashed cross edges, got %v:\n%v", got, out)
+	}
+
+	if _, err := wiki.Tree("missing.wiki"); err == nil {
+		t.Errorf("Expected an error for a missing root")
+	}
+}
+
+func TestOnly(t *testing.T) {
+	wiki := Wiki{
+		graph: map[string][]string{
+			"index.wiki": {"a.wiki", "b.wiki", "c.wiki"},
+			"a.wiki":     {"b.wiki", "d.wiki"},
+			"b.wiki":     {"index.wiki"},
+			"d.wiki":     {"a.wiki"},
+		},
+		edges:   map[[2]string]*Edge{{"a.wiki", "b.wiki"}: {Label: "see b", Weight: 2}},
+		present: map[string]bool{"index.wiki": true, "a.wiki": true, "b.wiki": true, "d.wiki": true},
+	}
+
+	c, unknown := wiki.Only("index.wiki", "a.wiki", "b.wiki", "x.wiki")
+	if fmt.Sprint(unknown) != "[x.wiki]" {
+		t.Errorf("Expected x.wiki to be unknown, got %v", unknown)
+	}
+	exp := "[[a.wiki b.wiki] [b.wiki index.wiki] [index.wiki a.wiki] [index.wiki b.wiki]]"
+	if got := fmt.Sprint(c.Edges()); got != exp {
+		t.Errorf("Expected edges %v, got %v", exp, got)
+	}
+	if e, ok := c.Edge("a.wiki", "b.wiki"); !ok || e.Label != "see b" {
+		t.Errorf("Expected the edge from a to b to be kept, got %+v", e)
+	}
+	if c.present["d.wiki"] || len(c.present) != 3 {
+		t.Errorf("Expected only the listed files to be present, got %v", c.present)
+	}
+	if len(wiki.graph["index.wiki"]) != 3 {
+		t.Errorf("Expected the original graph to be unchanged, got %v", wiki.graph)
+	}
+
+	// a listed node without links to other listed nodes is kept
+	c, _ = wiki.Only("c.wiki", "d.wiki")
+	if got := fmt.Sprint(c.Nodes(), c.Edges()); got != "[c.wiki d.wiki] []" {
+		t.Errorf("Expected two separate nodes, got %v", got)
+	}
+}
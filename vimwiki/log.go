@@ -0,0 +1,29 @@
+package vimwiki
+
+import (
+	"fmt"
+	"os"
+)
+
+// Levels of detail of the messages logged while walking, see wiki.Verbosity.
+const (
+	// Warnings, e.g. about files that cannot be read, are always logged
+	LogWarnings = iota
+	// Every directory that is entered or skipped
+	LogDirs
+	// Every link that is matched and the node it is renamed to
+	LogLinks
+)
+
+// logf writes the formatted message to wiki.Log, or to stderr if wiki.Log is
+// nil, when wiki.Verbosity is at least level.
+func (wiki *Wiki) logf(level int, format string, args ...interface{}) {
+	if level > wiki.Verbosity {
+		return
+	}
+	w := wiki.Log
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format+"\n", args...)
+}
@@ -0,0 +1,787 @@
+// Package vimwiki builds the graph of links between the files in a vimwiki
+// directory and converts it into the DOT language.
+package vimwiki
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const wikiref string = `\[\[((?:\\[\[\]]|[^\[\]])*)\]\]`
+const markdownref string = `\[((?:\\[\[\]]|[^\[\]])*)\]\(((?:[^()]|\([^()]*\))*)\)`
+const referenceref string = `\[([^\[\]]+)\]\[([^\[\]]*)\]`
+const definitionref string = `(?m)^ {0,3}\[([^\[\]]+)\]:[ \t]*<?([^\s>]+)>?`
+const urlref string = `https?://[^\s<>()\[\]"']+`
+const interwikiref string = `^(?:wiki\d+|wn\.[^:\s]+):`
+const taskref string = `^\s*(?:[-*+#]|\d+[.)])\s+\[[ .oOxX-]\]\s`
+const tagref string = `^:(?:[^:]+:)+$`
+const headingref string = `^\s*(?:#+\s+(.*?\S)|=+\s*(.*?\S)\s*=+)\s*$`
+
+// Page holds all information extracted from a single file.
+type Page struct {
+	// First heading of the file
+	Title string `json:"title,omitempty"`
+	// Links to other files
+	Links []Link `json:"links"`
+	// Whether the file is binary, e.g. an image, in which case it is not
+	// searched for links
+	Binary bool `json:"binary,omitempty"`
+	// Links with invalid syntax, e.g. [[]], which are not in Links
+	Malformed []Malformed `json:"malformed,omitempty"`
+	// Tags of the file, i.e. :tag1:tag2:, in order of appearance
+	Tags []string `json:"tags,omitempty"`
+	// Whether the text of the file, apart from headings, is a single link
+	// to another note, i.e. the file redirects to that note
+	Redirect bool `json:"redirect,omitempty"`
+	// Number of words of the file outside of any front matter
+	Words int `json:"words,omitempty"`
+	// All headings of the file, in order of appearance
+	Headings []string `json:"headings,omitempty"`
+}
+
+// Malformed is a link with invalid syntax, e.g. an empty link [[]].
+type Malformed struct {
+	// Text of the link
+	Link string `json:"link"`
+	// Why the link is invalid
+	Reason string `json:"reason"`
+	// Line of the link in the file, starting at 1, only set by Parse
+	Line int `json:"line,omitempty"`
+}
+
+// Link is a reference to another file.
+type Link struct {
+	// Path of the referenced file
+	Target string `json:"target"`
+	// Description of the link, i.e. [[link|description]] or
+	// [description](link)
+	Description string `json:"description,omitempty"`
+	// Whether the link refers to a file other than a note, e.g. an image
+	Asset bool `json:"asset,omitempty"`
+	// Whether the link refers to an external website, i.e. a http(s) URL
+	External bool `json:"external,omitempty"`
+	// Section within the referenced file, i.e. [description](link#anchor)
+	Anchor string `json:"anchor,omitempty"`
+	// Whether the link refers to a page in another wiki, i.e. [[wiki1:page]]
+	// or [[wn.name:page]]
+	InterWiki bool `json:"interwiki,omitempty"`
+	// Line of the link in the file, starting at 1, only set by Parse
+	Line int `json:"line,omitempty"`
+	// Whether the link is part of a checkbox item, i.e. - [ ] task [[link]]
+	Task bool `json:"task,omitempty"`
+	// Whether the referenced file is embedded, i.e. ![[link]]
+	Embed bool `json:"embed,omitempty"`
+}
+
+// Parser extracts links in vimwiki and markdown syntax from text.
+type Parser struct {
+	// Contains all regular expressions to match links
+	wikilink     *regexp.Regexp
+	markdownlink *regexp.Regexp
+	reference    *regexp.Regexp
+	definition   *regexp.Regexp
+	url          *regexp.Regexp
+	interwiki    *regexp.Regexp
+	task         *regexp.Regexp
+	tag          *regexp.Regexp
+	heading      *regexp.Regexp
+
+	// Skip links in markdown syntax, including reference links
+	NoMarkdown bool
+	// Skip links in vimwiki syntax
+	NoWiki bool
+	// Key of the list of links in a leading YAML front matter block, e.g.
+	// links: [a, b], or empty to treat the front matter as any other text
+	FrontMatterKey string
+	// Only extract the links listed under FrontMatterKey, skipping any link
+	// in the remaining text
+	FrontMatterOnly bool
+	// Extension appended to links without one, e.g. .md for a wiki in
+	// markdown syntax, or empty to append .wiki to links in vimwiki syntax
+	// and .md to links in markdown syntax
+	DefaultExt string
+}
+
+// NewParser returns a Parser with all regular expressions compiled.
+func NewParser() (*Parser, error) {
+	wikilink, err := regexp.Compile(wikiref)
+	if err != nil {
+		return nil, err
+	}
+
+	markdownlink, err := regexp.Compile(markdownref)
+	if err != nil {
+		return nil, err
+	}
+
+	reference, err := regexp.Compile(referenceref)
+	if err != nil {
+		return nil, err
+	}
+
+	definition, err := regexp.Compile(definitionref)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := regexp.Compile(urlref)
+	if err != nil {
+		return nil, err
+	}
+
+	interwiki, err := regexp.Compile(interwikiref)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := regexp.Compile(taskref)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := regexp.Compile(tagref)
+	if err != nil {
+		return nil, err
+	}
+
+	heading, err := regexp.Compile(headingref)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{
+		wikilink:     wikilink,
+		markdownlink: markdownlink,
+		reference:    reference,
+		definition:   definition,
+		url:          url,
+		interwiki:    interwiki,
+		task:         task,
+		tag:          tag,
+		heading:      heading,
+	}, nil
+}
+
+// Parse extracts the Page from the text read from r.
+func (p *Parser) Parse(r io.Reader) (Page, error) {
+	page := Page{Links: make([]Link, 0)}
+
+	// reference links are resolved once the whole text is known
+	var body strings.Builder
+
+	// lines are scanned without their line ending, including the \r of
+	// files with CRLF line endings
+	line := 0
+	front, list := false, false
+	// number of lines other than headings, and whether each is just a link
+	content, redirect := 0, true
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		// links listed in a leading front matter block, delimited by ---
+		// and --- or ...
+		if p.FrontMatterKey != "" && (front || line == 1 && strings.TrimSpace(text) == "---") {
+			t := strings.TrimSpace(text)
+			if line == 1 {
+				front = true
+			} else if t == "---" || t == "..." {
+				front = false
+			} else {
+				var entries []string
+				entries, list = p.frontMatter(text, list)
+				for _, e := range entries {
+					page.Links = append(page.Links, p.frontMatterLink(e, line))
+				}
+			}
+
+			// keep the line numbers of any reference links
+			body.WriteString("\n")
+			continue
+		}
+
+		heading := p.Heading(text)
+		if page.Title == "" {
+			page.Title = heading
+		}
+		// markers of headings, e.g. = or #, are not words
+		if heading != "" {
+			page.Headings = append(page.Headings, heading)
+			page.Words += len(strings.Fields(heading))
+		} else {
+			page.Words += len(strings.Fields(text))
+		}
+		if heading == "" && strings.TrimSpace(text) != "" {
+			content++
+			redirect = redirect && p.isLink(text)
+		}
+		for _, tag := range p.Tags(text) {
+			if unique(tag, page.Tags) {
+				page.Tags = append(page.Tags, tag)
+			}
+		}
+		if p.FrontMatterKey != "" && p.FrontMatterOnly {
+			continue
+		}
+		task := p.IsTask(text)
+		for _, l := range p.inlineLinks(text) {
+			l.Line = line
+			l.Task = task
+			page.Links = append(page.Links, l)
+		}
+		for _, m := range p.Malformed(text) {
+			m.Line = line
+			page.Malformed = append(page.Malformed, m)
+		}
+		body.WriteString(text)
+		body.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return page, err
+	}
+
+	if !p.NoMarkdown && !(p.FrontMatterKey != "" && p.FrontMatterOnly) {
+		text := body.String()
+		links, offsets := p.references(text)
+		for i, l := range links {
+			l.Line = strings.Count(text[:offsets[i]], "\n") + 1
+			page.Links = append(page.Links, l)
+		}
+	}
+	page.Redirect = content == 1 && redirect && len(page.Links) == 1 &&
+		!page.Links[0].External && !page.Links[0].Asset
+	return page, nil
+}
+
+// isLink returns true when text consists of nothing but a single vimwiki or
+// markdown link, apart from surrounding whitespace.
+func (p *Parser) isLink(text string) bool {
+	text = strings.TrimSpace(text)
+	for _, re := range []*regexp.Regexp{p.wikilink, p.markdownlink} {
+		if loc := re.FindStringIndex(text); loc != nil && loc[0] == 0 && loc[1] == len(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// options describes the settings of p, such that pages extracted with
+// different settings can be told apart.
+func (p *Parser) options() string {
+	return fmt.Sprintf("wiki=%t markdown=%t wikiref=%q markdownref=%q frontmatter=%q only=%t ext=%q",
+		!p.NoWiki, !p.NoMarkdown, p.wikilink, p.markdownlink, p.FrontMatterKey, p.FrontMatterOnly, p.DefaultExt)
+}
+
+// extension returns the extension appended to links without one, i.e.
+// p.DefaultExt if set or ext otherwise.
+func (p *Parser) extension(ext string) string {
+	if p.DefaultExt != "" {
+		return p.DefaultExt
+	}
+	return ext
+}
+
+// frontMatter returns the entries of the list under p.FrontMatterKey in the
+// line text of a YAML front matter block, either inline, i.e. key: [a, b] or
+// key: a, or one per line following key:, i.e. - a. Whether text is inside
+// such a list is passed in by list and returned.
+func (p *Parser) frontMatter(text string, list bool) ([]string, bool) {
+	t := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, p.FrontMatterKey+":"):
+		value := strings.TrimSpace(text[len(p.FrontMatterKey)+1:])
+		if value == "" {
+			// entries follow on the next lines
+			return nil, true
+		}
+		if strings.HasPrefix(value, "[") && !strings.HasPrefix(value, "[[") {
+			return unquoteAll(strings.Split(strings.Trim(value, "[]"), ",")), false
+		}
+		return unquoteAll([]string{value}), false
+	case list && strings.HasPrefix(t, "- "):
+		return unquoteAll([]string{t[2:]}), true
+	case list && t == "":
+		return nil, true
+	}
+	return nil, false
+}
+
+// unquoteAll removes surrounding whitespace and quotes from each entry,
+// leaving out any entry that is empty.
+func unquoteAll(entries []string) []string {
+	var clean []string
+	for _, e := range entries {
+		e = strings.Trim(strings.TrimSpace(e), `"'`)
+		if e != "" {
+			clean = append(clean, e)
+		}
+	}
+	return clean
+}
+
+// frontMatterLink returns the link to entry of a front matter list on line,
+// where entry is a page as in a vimwiki link, e.g. note, note.md or [[note]].
+func (p *Parser) frontMatterLink(entry string, line int) Link {
+	target := p.ParseWikiLinks(entry)
+	return Link{
+		Target:    target,
+		External:  isURL(target),
+		Anchor:    p.WikiAnchor(entry),
+		InterWiki: p.interwiki.MatchString(target),
+		Line:      line,
+	}
+}
+
+// SetLinkPatterns replaces the regular expressions matching links in vimwiki
+// and markdown syntax, where an empty pattern keeps the current expression.
+// The first group of wiki must capture the link, i.e. link|description, while
+// the first two groups of markdown must capture the description and the
+// target of the link.
+func (p *Parser) SetLinkPatterns(wiki, markdown string) error {
+	if wiki != "" {
+		re, err := regexp.Compile(wiki)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for wiki links: %v", err)
+		}
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("pattern %q for wiki links needs a capture group for the link", wiki)
+		}
+		p.wikilink = re
+	}
+	if markdown != "" {
+		re, err := regexp.Compile(markdown)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for markdown links: %v", err)
+		}
+		if re.NumSubexp() < 2 {
+			return fmt.Errorf("pattern %q for markdown links needs capture groups for the description and the target", markdown)
+		}
+		p.markdownlink = re
+	}
+	return nil
+}
+
+// Heading returns the title of a markdown `# Title` or vimwiki `= Title =`
+// heading in text, or an empty string if text is not a heading.
+func (p *Parser) Heading(text string) string {
+	m := p.heading.FindStringSubmatch(text)
+	if m == nil {
+		return ""
+	}
+	if m[1] != "" {
+		return m[1]
+	}
+	return m[2]
+}
+
+// Malformed returns all links in text with invalid syntax: links without
+// target, e.g. [[]], [[ ]] or [description](), links containing control
+// characters, e.g. a newline, and vimwiki links that are not closed.
+func (p *Parser) Malformed(text string) []Malformed {
+	var malformed []Malformed
+	if !p.NoWiki {
+		for _, m := range p.wikilink.FindAllStringSubmatch(text, -1) {
+			target := strings.SplitN(m[1], "|", 2)[0]
+			if strings.TrimSpace(target) == "" {
+				malformed = append(malformed, Malformed{Link: m[0], Reason: "empty link"})
+			} else if strings.IndexFunc(target, unicode.IsControl) >= 0 {
+				malformed = append(malformed, Malformed{Link: m[0], Reason: "illegal character"})
+			}
+		}
+		text = p.wikilink.ReplaceAllString(text, "")
+		if idx := strings.Index(text, "[["); idx >= 0 {
+			malformed = append(malformed, Malformed{Link: text[idx:], Reason: "unterminated link"})
+		}
+	}
+	if !p.NoMarkdown {
+		for _, m := range p.markdownlink.FindAllStringSubmatch(text, -1) {
+			if strings.TrimSpace(m[2]) == "" {
+				malformed = append(malformed, Malformed{Link: m[0], Reason: "empty link"})
+			}
+		}
+	}
+	return malformed
+}
+
+// Tags returns the vimwiki tags in text, i.e. tag1 and tag2 for :tag1:tag2:,
+// surrounded by whitespace.
+func (p *Parser) Tags(text string) []string {
+	var tags []string
+	for _, word := range strings.Fields(text) {
+		if p.tag.MatchString(word) {
+			tags = append(tags, strings.Split(strings.Trim(word, ":"), ":")...)
+		}
+	}
+	return tags
+}
+
+// IsTask returns true when text is a checkbox item of a list, e.g. - [ ] or
+// * [X], such that its links refer to a task.
+func (p *Parser) IsTask(text string) bool {
+	return p.task.MatchString(text)
+}
+
+// Links returns all links to other notes available in text. Links in
+// markdown or vimwiki syntax are skipped if p.NoMarkdown or p.NoWiki is set.
+func (p *Parser) Links(text string) []string {
+	targets := make([]string, 0)
+	for _, l := range p.ParseLinks(text) {
+		if !l.Asset && !l.External {
+			targets = append(targets, l.Target)
+		}
+	}
+	return targets
+}
+
+// ParseLinks returns all links available in text including their
+// descriptions. Links to files other than notes, e.g. images, are marked as
+// assets.
+func (p *Parser) ParseLinks(text string) []Link {
+	links := p.inlineLinks(text)
+	if !p.NoMarkdown {
+		links = append(links, p.ReferenceLinks(text)...)
+	}
+	return links
+}
+
+// ReferenceLinks returns all markdown reference links, i.e. [text][ref] or
+// [ref][], in text that refer to a definition [ref]: url elsewhere in text.
+// References without definition are ignored.
+func (p *Parser) ReferenceLinks(text string) []Link {
+	links, _ := p.references(text)
+	return links
+}
+
+// references returns the reference links in text, see ReferenceLinks,
+// together with the offset of each reference in text.
+func (p *Parser) references(text string) ([]Link, []int) {
+	// labels of references are case insensitive
+	urls := make(map[string]string)
+	for _, m := range p.definition.FindAllStringSubmatch(text, -1) {
+		label := strings.ToLower(m[1])
+		if _, ok := urls[label]; !ok {
+			urls[label] = m[2]
+		}
+	}
+
+	links := make([]Link, 0)
+	offsets := make([]int, 0)
+	for _, idx := range p.reference.FindAllStringSubmatchIndex(text, -1) {
+		description := text[idx[2]:idx[3]]
+		label := text[idx[4]:idx[5]]
+		if label == "" {
+			label = description
+		}
+		url, ok := urls[strings.ToLower(label)]
+		if !ok {
+			continue
+		}
+
+		if link, ok := p.markdownLink(url, description); ok {
+			links = append(links, link)
+			offsets = append(offsets, idx[0])
+		}
+	}
+	return links, offsets
+}
+
+// inlineLinks returns all links in text that do not depend on any other
+// text, i.e. all links except reference links.
+func (p *Parser) inlineLinks(text string) []Link {
+	links := make([]Link, 0)
+
+	// wiki syntax
+	if !p.NoWiki {
+		for _, idx := range p.wikilink.FindAllStringSubmatchIndex(text, -1) {
+			// the first group holds the link, i.e. link|description
+			m := text[idx[2]:idx[3]]
+			target := p.ParseWikiLinks(m)
+			if target == "" {
+				// section within the same file, i.e. [[#section]]
+				continue
+			}
+			links = append(links, Link{
+				Target:      target,
+				Description: p.WikiDescription(m),
+				External:    isURL(target),
+				Anchor:      p.WikiAnchor(m),
+				InterWiki:   p.interwiki.MatchString(target),
+				Embed:       idx[0] > 0 && text[idx[0]-1] == '!',
+			})
+		}
+		text = p.wikilink.ReplaceAllString(text, "")
+	}
+
+	// markdown syntax
+	if !p.NoMarkdown {
+		// the groups hold the description and the target
+		for _, m := range p.markdownlink.FindAllStringSubmatch(text, -1) {
+			if link, ok := p.markdownLink(m[2], unescapeBrackets(m[1])); ok {
+				links = append(links, link)
+			}
+		}
+		text = p.markdownlink.ReplaceAllString(text, "")
+	}
+
+	// bare URLs outside of any other link
+	for _, url := range p.URLs(text) {
+		links = append(links, Link{Target: url, External: true})
+	}
+	return links
+}
+
+// URLs matches on all http(s) URLs in text. Trailing punctuation, e.g. of a
+// sentence ending in a URL, is not considered part of the URL.
+func (p *Parser) URLs(text string) []string {
+	urls := p.url.FindAllString(text, -1)
+	for i, u := range urls {
+		urls[i] = strings.TrimRight(u, ".,;:!?")
+	}
+	return urls
+}
+
+// markdownLink returns the Link of a markdown link to target with the given
+// description. Links to sections within the same file, i.e. [x](#section),
+// are not considered links and return false.
+func (p *Parser) markdownLink(target, description string) (Link, bool) {
+	target = destination(target)
+	if isURL(target) {
+		return Link{Target: target, Description: description, External: true}, true
+	}
+
+	target, anchor := splitAnchor(target)
+	target, anchor = trimQuery(target), trimQuery(anchor)
+	if target == "" {
+		return Link{}, false
+	}
+	target = unescape(target)
+
+	link := Link{
+		Target:      p.markdownTarget(target),
+		Description: description,
+		Anchor:      anchor,
+	}
+	if link.Target == "" {
+		link.Target = target
+		link.Asset = true
+	}
+	return link, true
+}
+
+// destination returns the target of a markdown link without surrounding
+// whitespace, angle brackets or title, i.e. <target> "title", such that it
+// refers to the same file as the equivalent vimwiki link.
+func destination(target string) string {
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(target, "<") {
+		if idx := strings.Index(target, ">"); idx > 0 {
+			return target[1:idx]
+		}
+	}
+	for _, quote := range []string{`"`, "'"} {
+		if !strings.HasSuffix(target, quote) || len(target) < 2 {
+			continue
+		}
+		if idx := strings.LastIndex(target[:len(target)-1], " "+quote); idx > 0 {
+			return strings.TrimSpace(target[:idx])
+		}
+	}
+	return target
+}
+
+// splitAnchor splits link into the file and the section within the file,
+// i.e. file#section.
+func splitAnchor(link string) (string, string) {
+	idx := strings.Index(link, "#")
+	if idx < 0 {
+		return link, ""
+	}
+	return link[:idx], link[idx+1:]
+}
+
+// isURL returns true when link refers to an external website.
+func isURL(link string) bool {
+	return strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://")
+}
+
+// WikiLinks matches on all vimwiki syntax links in text.
+func (p *Parser) WikiLinks(text string) []string {
+	return p.wikilink.FindAllString(text, -1)
+}
+
+// MarkdownLinks matches on all markdown syntax links in text.
+func (p *Parser) MarkdownLinks(text string) []string {
+	return p.markdownlink.FindAllString(text, -1)
+}
+
+// MarkdownTarget extracts the target from markdown syntax links as is.
+func (p *Parser) MarkdownTarget(link string) string {
+	idx := strings.Index(link, "](")
+	if idx < 0 {
+		return ""
+	}
+
+	// the target ends at the closing parenthesis, such that any trailing
+	// punctuation, e.g. [x](y.md)., is not part of it, while one level of
+	// balanced parentheses is kept, e.g. [x](note (1).md)
+	target := link[idx+2:]
+	depth := 0
+	for i, r := range target {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return target[:i]
+			}
+			depth--
+		}
+	}
+	return target
+}
+
+// ParseMarkdownLinks extracts the filename from markdown syntax links. Any
+// section, i.e. file.md#section, query string, i.e. file.md?x=1, or trailing
+// slash, i.e. file/, is removed from the filename.
+func (p *Parser) ParseMarkdownLinks(link string) string {
+	link = destination(p.MarkdownTarget(link))
+	if isURL(link) {
+		return link
+	}
+	link, _ = splitAnchor(link)
+	link = trimQuery(link)
+	if link == "" {
+		return ""
+	}
+	return p.markdownTarget(unescape(link))
+}
+
+// trimQuery removes any query string, i.e. page?x=1, and trailing slashes,
+// i.e. page/, from the target or section of a markdown link.
+func trimQuery(link string) string {
+	if idx := strings.Index(link, "?"); idx >= 0 {
+		link = link[:idx]
+	}
+	return strings.TrimRight(link, "/")
+}
+
+// unescape decodes any percent-encoded characters in link, e.g. %20 for a
+// space, or returns link as is when it is not validly encoded.
+func unescape(link string) string {
+	decoded, err := url.PathUnescape(link)
+	if err != nil {
+		return link
+	}
+	return decoded
+}
+
+// markdownTarget returns the filename of target of a markdown link, or an
+// empty string if target is not a note.
+func (p *Parser) markdownTarget(link string) string {
+	if isURL(link) {
+		return link
+	}
+
+	ext := filepath.Ext(link)
+	if ext == ".md" || ext == ".wiki" {
+		return link
+	}
+
+	// assume it refers to a local markdown file
+	if ext == "" {
+		return link + p.extension(".md")
+	}
+
+	// if ext is anything else, we should probably skip the file
+	return ""
+}
+
+// MarkdownDescription extracts the description from markdown syntax links.
+func (p *Parser) MarkdownDescription(link string) string {
+	idx := strings.Index(link, "](")
+	if idx < 0 {
+		return ""
+	}
+	return unescapeBrackets(strings.TrimPrefix(link[:idx], "["))
+}
+
+// WikiDescription extracts the description from vimwiki syntax links, i.e.
+// [[link|description]], or returns an empty string if there is none.
+func (p *Parser) WikiDescription(link string) string {
+	link = trimBrackets(link)
+	idx := strings.Index(link, "|")
+	if idx < 0 {
+		return ""
+	}
+	return unescapeBrackets(link[idx+1:])
+}
+
+// WikiAnchor extracts the section from vimwiki syntax links, i.e.
+// [[link#section]] or [[link#section|description]].
+func (p *Parser) WikiAnchor(link string) string {
+	link = wikiTarget(link)
+	if isURL(link) || p.interwiki.MatchString(link) {
+		return ""
+	}
+	_, anchor := splitAnchor(link)
+	return anchor
+}
+
+// wikiTarget returns the target of vimwiki syntax links without the
+// description.
+func wikiTarget(link string) string {
+	link = trimBrackets(link)
+
+	// split of description [[link|description]]
+	idx := strings.Index(link, "|")
+	if idx > 0 {
+		link = link[:idx]
+	}
+	return strings.TrimSpace(unescapeBrackets(link))
+}
+
+// trimBrackets removes the brackets surrounding a vimwiki link, i.e. [[link]],
+// but keeps a trailing escaped bracket, e.g. the last bracket of [[a \[b\]]].
+func trimBrackets(link string) string {
+	link = strings.TrimLeft(link, "[")
+	for strings.HasSuffix(link, "]") && !strings.HasSuffix(link, `\]`) {
+		link = link[:len(link)-1]
+	}
+	return link
+}
+
+// unescapeBrackets replaces the escaped brackets \[ and \] in text of a link
+// by plain brackets.
+func unescapeBrackets(text string) string {
+	return strings.NewReplacer(`\[`, "[", `\]`, "]").Replace(text)
+}
+
+// ParseWikiLinks extracts the filename from vimwiki syntax links. Links to
+// other wikis, i.e. [[wiki1:page]], are returned as is without extension.
+// Any section is removed, i.e. [[file#section]], such that an empty string
+// is returned for links to a section within the same file.
+func (p *Parser) ParseWikiLinks(link string) string {
+	// [[file]] -> dir/file.wiki
+	link = wikiTarget(link)
+	if isURL(link) || p.interwiki.MatchString(link) {
+		return link
+	}
+
+	link, _ = splitAnchor(link)
+	if link == "" {
+		return ""
+	}
+
+	ext := filepath.Ext(link)
+	if ext != ".md" && ext != ".wiki" {
+		link += p.extension(".wiki")
+	}
+	return link
+}
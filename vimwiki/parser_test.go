@@ -0,0 +1,809 @@
+package vimwiki
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type match struct {
+	text    string
+	matches []string
+	links   []string
+	dir     []string
+	ignore  string
+}
+
+func TestMatchParseMarkdownLinks(t *testing.T) {
+	cases := []match{
+		match{
+			text:    "[link](url)",
+			matches: []string{"[link](url)"},
+			links:   []string{"url.md"},
+			ignore:  "",
+		},
+		match{
+			text:    "[link](url.md)",
+			matches: []string{"[link](url.md)"},
+			links:   []string{"url.md"},
+			ignore:  "",
+		},
+		match{
+			text:    "[link](vimwiki.wiki)",
+			matches: []string{"[link](vimwiki.wiki)"},
+			links:   []string{"vimwiki.wiki"},
+			ignore:  "",
+		},
+		match{
+			text:    "![figure](image.png)",
+			matches: []string{"[figure](image.png)"},
+			links:   []string{""},
+			ignore:  "",
+		},
+		match{
+			text:    "see [link](url.md).",
+			matches: []string{"[link](url.md)"},
+			links:   []string{"url.md"},
+			ignore:  "",
+		},
+		match{
+			text:    "[a](x.md), [b](y)",
+			matches: []string{"[a](x.md)", "[b](y)"},
+			links:   []string{"x.md", "y.md"},
+			ignore:  "",
+		},
+		match{
+			text:    "(see [link](url))",
+			matches: []string{"[link](url)"},
+			links:   []string{"url.md"},
+			ignore:  "",
+		},
+	}
+
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		matches := p.MarkdownLinks(c.text)
+
+		if len(matches) != len(c.matches) {
+			t.Errorf("Expected %d matches, got %d matches", len(c.matches), len(matches))
+		}
+
+		for i, m := range matches {
+			if m != c.matches[i] {
+				t.Errorf("Expected match %v, got %v", c.matches[i], m)
+			}
+		}
+
+		for i, m := range matches {
+			link := p.ParseMarkdownLinks(m)
+			if link != c.links[i] {
+				t.Errorf("Expected link: %v, got %v", c.links[i], link)
+			}
+		}
+	}
+}
+
+func TestMatchParseWikiLinks(t *testing.T) {
+	cases := []match{
+		match{
+			text:    "[[link]]",
+			matches: []string{"[[link]]"},
+			links:   []string{"link.wiki"},
+		},
+		match{
+			text:    "[[a]]\n[[b]]",
+			matches: []string{"[[a]]", "[[b]]"},
+			links:   []string{"a.wiki", "b.wiki"},
+			ignore:  "",
+		},
+		match{
+			text:    "[[link|description]]",
+			matches: []string{"[[link|description]]"},
+			links:   []string{"link.wiki"},
+			ignore:  "",
+		},
+		match{
+			text:    "[[link.wiki]]",
+			matches: []string{"[[link.wiki]]"},
+			links:   []string{"link.wiki"},
+			ignore:  "",
+		},
+		match{
+			text:    "[[link.md]]",
+			matches: []string{"[[link.md]]"},
+			links:   []string{"link.md"},
+			ignore:  "",
+		},
+	}
+
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		matches := p.WikiLinks(c.text)
+
+		if len(matches) != len(c.matches) {
+			t.Errorf("Expected %d matches, got %d matches", len(c.matches), len(matches))
+		}
+
+		for i, m := range matches {
+			if m != c.matches[i] {
+				t.Errorf("Expected match %v, got %v", c.matches[i], m)
+			}
+		}
+
+		for i, m := range matches {
+			link := p.ParseWikiLinks(m)
+			if link != c.links[i] {
+				t.Errorf("Expected link: %v, got %v", c.links[i], link)
+			}
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{"foo.wiki", "bar.md"}
+	links := p.Links("[[foo|description]] and [bar](bar)")
+	if len(links) != len(exp) {
+		t.Fatalf("Expected %d links, got %d links", len(exp), len(links))
+	}
+	for i, l := range links {
+		if l != exp[i] {
+			t.Errorf("Expected link: %v, got %v", exp[i], l)
+		}
+	}
+}
+
+func TestHeading(t *testing.T) {
+	cases := map[string]string{
+		"# Title":            "Title",
+		"### Sub title  ":    "Sub title",
+		"= Title =":          "Title",
+		"==  Sub title  ==":  "Sub title",
+		"#hashtag":           "",
+		"text = not a title": "",
+		"":                   "",
+	}
+
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for text, exp := range cases {
+		if title := p.Heading(text); title != exp {
+			t.Errorf("Expected title %q for %q, got %q", exp, text, title)
+		}
+	}
+}
+
+func TestParseLinksDescription(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []Link{
+		{Target: "link.wiki", Description: "description"},
+		{Target: "other.wiki"},
+		{Target: "url.md", Description: "text"},
+	}
+	links := p.ParseLinks("[[link|description]] [[other]]\n[text](url)")
+	if len(links) != len(exp) {
+		t.Fatalf("Expected %d links, got %d links", len(exp), len(links))
+	}
+	for i, l := range links {
+		if l != exp[i] {
+			t.Errorf("Expected link: %v, got %v", exp[i], l)
+		}
+	}
+}
+
+func TestParseLinksAssets(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{"image.png", "paper.pdf", "photo.jpg"} {
+		links := p.ParseLinks("![figure](" + target + ")")
+		exp := Link{Target: target, Description: "figure", Asset: true}
+		if len(links) != 1 || links[0] != exp {
+			t.Errorf("Expected asset link %v, got %v", exp, links)
+		}
+		if links := p.Links("![figure](" + target + ")"); len(links) != 0 {
+			t.Errorf("Expected no links to notes, got %v", links)
+		}
+	}
+}
+
+func TestReferenceLinks(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := "See [the notes][notes] and [Other][] or [missing][none].\n" +
+		"\n" +
+		"[notes]: notes.md\n" +
+		"  [other]: <other> \"title\"\n" +
+		"[notes]: ignored.md\n"
+
+	exp := []Link{
+		{Target: "notes.md", Description: "the notes"},
+		{Target: "other.md", Description: "Other"},
+	}
+	links := p.ReferenceLinks(text)
+	if len(links) != len(exp) {
+		t.Fatalf("Expected %d links, got %v", len(exp), links)
+	}
+	for i, l := range links {
+		if l != exp[i] {
+			t.Errorf("Expected link: %v, got %v", exp[i], l)
+		}
+	}
+
+	// definitions on separate lines are resolved when parsing a file
+	page, err := p.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Links) != len(exp) {
+		t.Errorf("Expected %d links in page, got %v", len(exp), page.Links)
+	}
+
+	if links := p.Links(text); len(links) != 2 || links[0] != "notes.md" {
+		t.Errorf("Expected reference links in Links, got %v", links)
+	}
+}
+
+func TestExternalLinks(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]Link{
+		"[x](https://a.com)":           {{Target: "https://a.com", Description: "x", External: true}},
+		"[[https://a.com/page|a]]":     {{Target: "https://a.com/page", Description: "a", External: true}},
+		"see https://a.com/x?y=1.":     {{Target: "https://a.com/x?y=1", External: true}},
+		"see <http://b.org> and [[c]]": {{Target: "c.wiki"}, {Target: "http://b.org", External: true}},
+	}
+	for text, exp := range cases {
+		links := p.ParseLinks(text)
+		if len(links) != len(exp) {
+			t.Errorf("Expected %v in %q, got %v", exp, text, links)
+			continue
+		}
+		for i, l := range links {
+			if l != exp[i] {
+				t.Errorf("Expected link: %v, got %v", exp[i], l)
+			}
+		}
+		if targets := p.Links(text); len(targets) != countNotes(exp) {
+			t.Errorf("Expected no URLs in Links, got %v", targets)
+		}
+	}
+
+	if link := p.ParseMarkdownLinks("[x](https://a.com)"); link != "https://a.com" {
+		t.Errorf("Expected URL without extension, got %v", link)
+	}
+}
+
+// countNotes returns the number of links that refer to other notes.
+func countNotes(links []Link) int {
+	n := 0
+	for _, l := range links {
+		if !l.Asset && !l.External {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMarkdownAnchors(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]Link{
+		"[text](page.md#a)":     {{Target: "page.md", Description: "text", Anchor: "a"}},
+		"[text](page#a)":        {{Target: "page.md", Description: "text", Anchor: "a"}},
+		"[text](#local)":        {},
+		"[x](https://a.com#id)": {{Target: "https://a.com#id", Description: "x", External: true}},
+	}
+	for text, exp := range cases {
+		links := p.ParseLinks(text)
+		if len(links) != len(exp) {
+			t.Errorf("Expected %v in %q, got %v", exp, text, links)
+			continue
+		}
+		for i, l := range links {
+			if l != exp[i] {
+				t.Errorf("Expected link: %v, got %v", exp[i], l)
+			}
+		}
+	}
+
+	if link := p.ParseMarkdownLinks("[text](page.md#a)"); link != "page.md" {
+		t.Errorf("Expected page.md, got %v", link)
+	}
+}
+
+func TestSpaces(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"[text](my%20note.md)":         "my note.md",
+		"[text](my%20note)":            "my note.md",
+		"[text](my note.md)":           "my note.md",
+		"[text](my%20note.md#sec%201)": "my note.md",
+		"[text](100%.md)":              "100%.md",
+		"[[my note]]":                  "my note.wiki",
+		"[[my note|with description]]": "my note.wiki",
+	}
+	for text, exp := range cases {
+		links := p.Links(text)
+		if len(links) != 1 || links[0] != exp {
+			t.Errorf("Expected link %q for %q, got %q", exp, text, links)
+		}
+	}
+}
+
+func TestMultipleMarkdownLinks(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []Link{
+		{Target: "x.md", Description: "a"},
+		{Target: "y.md", Description: "b"},
+	}
+	links := p.ParseLinks("[a](x.md) and [b](y.md)")
+	if len(links) != len(exp) {
+		t.Fatalf("Expected %v, got %v", exp, links)
+	}
+	for i, l := range links {
+		if l != exp[i] {
+			t.Errorf("Expected link: %v, got %v", exp[i], l)
+		}
+	}
+}
+
+func TestDisableSyntax(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := "[[a]] [b](b.md) [c][]\n\n[c]: c.md"
+	cases := []struct {
+		noWiki, noMarkdown bool
+		links              string
+	}{
+		{false, false, "[a.wiki b.md c.md]"},
+		{true, false, "[b.md c.md]"},
+		{false, true, "[a.wiki]"},
+		{true, true, "[]"},
+	}
+	for _, c := range cases {
+		p.NoWiki, p.NoMarkdown = c.noWiki, c.noMarkdown
+		if links := fmt.Sprint(p.Links(text)); links != c.links {
+			t.Errorf("Expected links %v without wiki %v and markdown %v, got %v",
+				c.links, c.noWiki, c.noMarkdown, links)
+		}
+
+		page, err := p.Parse(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Links) != strings.Count(c.links, ".") {
+			t.Errorf("Expected links %v in page, got %v", c.links, page.Links)
+		}
+	}
+}
+
+func TestMarkdownLinksPerLine(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]string{
+		"[a](x.md) and [b](y.md)":     {"[a](x.md)", "[b](y.md)"},
+		"[see] foo (bar) [a](x.md)":   {"[a](x.md)"},
+		"[a (b)](x.md), (see [c](y))": {"[a (b)](x.md)", "[c](y)"},
+		"[see] foo (bar)":             {},
+	}
+	for text, exp := range cases {
+		matches := p.MarkdownLinks(text)
+		if fmt.Sprint(matches) != fmt.Sprint(exp) {
+			t.Errorf("Expected matches %q in %q, got %q", exp, text, matches)
+		}
+	}
+
+	if links := fmt.Sprint(p.Links("[a (b)](x.md), (see [c](y))")); links != "[x.md y.md]" {
+		t.Errorf("Expected links [x.md y.md], got %v", links)
+	}
+}
+
+func TestInterWikiLinks(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]Link{
+		"[[wiki0:index]]":             {Target: "wiki0:index", InterWiki: true},
+		"[[wiki12:sub/page|desc]]":    {Target: "wiki12:sub/page", Description: "desc", InterWiki: true},
+		"[[wn.work:todo]]":            {Target: "wn.work:todo", InterWiki: true},
+		"[[wikipedia:notes]]":         {Target: "wikipedia:notes.wiki"},
+		"[[notes about wiki0:index]]": {Target: "notes about wiki0:index.wiki"},
+	}
+	for text, exp := range cases {
+		links := p.ParseLinks(text)
+		if len(links) != 1 || links[0] != exp {
+			t.Errorf("Expected %v for %q, got %v", exp, text, links)
+		}
+	}
+}
+
+func TestTaskLines(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"- [ ] do [[thing]]":       true,
+		"  * [X] done [[thing]]":   true,
+		"1. [.] partly [[thing]]":  true,
+		"- [[thing]]":              false,
+		"see [ ] [[thing]]":        false,
+		"- [a](thing.md) and more": false,
+	}
+	for text, exp := range cases {
+		page, err := p.Parse(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Links) != 1 {
+			t.Fatalf("Expected one link in %q, got %v", text, page.Links)
+		}
+		if page.Links[0].Task != exp {
+			t.Errorf("Expected task %v for %q, got %v", exp, text, page.Links[0].Task)
+		}
+	}
+}
+
+func TestEmbeds(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]Link{
+		"![[note]]":         {{Target: "note.wiki", Embed: true}},
+		"![[note#section]]": {{Target: "note.wiki", Anchor: "section", Embed: true}},
+		"[[note#a|text]]":   {{Target: "note.wiki", Description: "text", Anchor: "a"}},
+		"see ![[a]], [[b]]": {{Target: "a.wiki", Embed: true}, {Target: "b.wiki"}},
+		"[[#section]]":      {},
+	}
+	for text, exp := range cases {
+		links := p.ParseLinks(text)
+		if fmt.Sprint(links) != fmt.Sprint(exp) {
+			t.Errorf("Expected links %+v in %q, got %+v", exp, text, links)
+		}
+	}
+}
+
+func TestLinkPatterns(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetLinkPatterns(`\{\{([^{}]*)\}\}`, `<([^<>|]*)\|([^<>]*)>`); err != nil {
+		t.Fatal(err)
+	}
+
+	links := p.ParseLinks("{{note|text}} <other|b.md> [[skipped]] [c](c.md)")
+	exp := []Link{
+		{Target: "note.wiki", Description: "text"},
+		{Target: "b.md", Description: "other"},
+	}
+	if fmt.Sprint(links) != fmt.Sprint(exp) {
+		t.Errorf("Expected links %+v, got %+v", exp, links)
+	}
+
+	for _, patterns := range [][2]string{
+		{`[[(`, ""},
+		{`\[\[.*\]\]`, ""},
+		{"", `\[(.*)\]`},
+	} {
+		if err := p.SetLinkPatterns(patterns[0], patterns[1]); err == nil {
+			t.Errorf("Expected an error for patterns %q", patterns)
+		}
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]Malformed{
+		"[[]] and [[ok]]":       {{Link: "[[]]", Reason: "empty link"}},
+		"[[ ]] and [[|text]]":   {{Link: "[[ ]]", Reason: "empty link"}, {Link: "[[|text]]", Reason: "empty link"}},
+		"[[some\nnote]]":        {{Link: "[[some\nnote]]", Reason: "illegal character"}},
+		"[text]() and [a](a)":   {{Link: "[text]()", Reason: "empty link"}},
+		"[[note]] and [[other":  {{Link: "[[other", Reason: "unterminated link"}},
+		"[[note]] [b](b.md) []": nil,
+	}
+	for text, exp := range cases {
+		if got := p.Malformed(text); fmt.Sprint(got) != fmt.Sprint(exp) {
+			t.Errorf("Expected malformed links %q in %q, got %q", exp, text, got)
+		}
+	}
+
+	// a link split over two lines is not closed on its first line
+	page, err := p.Parse(strings.NewReader("= Title =\n[[some\nnote]] [[ok]]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []Malformed{{Link: "[[some", Reason: "unterminated link", Line: 2}}
+	if fmt.Sprint(page.Malformed) != fmt.Sprint(exp) {
+		t.Errorf("Expected malformed links %v, got %v", exp, page.Malformed)
+	}
+	if links := fmt.Sprint(page.Links); links != fmt.Sprint([]Link{{Target: "ok.wiki", Line: 3}}) {
+		t.Errorf("Expected only the valid link, got %v", links)
+	}
+}
+
+func TestCRLF(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lf, err := p.Parse(strings.NewReader("= Title =\n[[link]]\n[ref][]\n\n[ref]: other.md\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlf, err := p.Parse(strings.NewReader("= Title =\r\n[[link]]\r\n[ref][]\r\n\r\n[ref]: other.md\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(crlf) != fmt.Sprint(lf) {
+		t.Errorf("Expected %+v for CRLF line endings, got %+v", lf, crlf)
+	}
+}
+
+func TestFrontMatter(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.FrontMatterKey = "links"
+
+	text := "---\ntitle: [[ignored]]\nlinks: [a, \"b.md\"]\n---\n= Title =\n[[c]]\n"
+	page, err := p.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := fmt.Sprint([]Link{{Target: "a.wiki", Line: 3}, {Target: "b.md", Line: 3}, {Target: "c.wiki", Line: 6}})
+	if got := fmt.Sprint(page.Links); got != exp {
+		t.Errorf("Expected links %v, got %v", exp, got)
+	}
+	if page.Title != "Title" {
+		t.Errorf("Expected title after the front matter, got %q", page.Title)
+	}
+
+	// entries on separate lines, skipping the remaining text
+	p.FrontMatterOnly = true
+	text = "---\nlinks:\n  - a\n  - '[[b]]'\ntags: [x]\n...\n[[c]] [d](d.md)\n\n[ref]: e.md"
+	page, err = p.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var targets []string
+	for _, l := range page.Links {
+		targets = append(targets, fmt.Sprintf("%v:%d", l.Target, l.Line))
+	}
+	if got := fmt.Sprint(targets); got != "[a.wiki:3 b.wiki:4]" {
+		t.Errorf("Expected only links in the front matter, got %v", got)
+	}
+
+	// a block not on the first line is no front matter
+	page, err = p.Parse(strings.NewReader("text\n---\nlinks: [a]\n---"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Links) != 0 {
+		t.Errorf("Expected no links without front matter, got %v", page.Links)
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"[a](<foo.wiki>)":            "foo.wiki",
+		"[a](<my note.md>)":          "my note.md",
+		`[a](foo.wiki "Foo")`:        "foo.wiki",
+		"[a](foo 'Foo')":             "foo.md",
+		"[a]( foo.wiki )":            "foo.wiki",
+		"[a](my note.md)":            "my note.md",
+		"[[ foo ]]":                  "foo.wiki",
+		"[[foo | with description]]": "foo.wiki",
+	}
+	for text, exp := range cases {
+		links := p.Links(text)
+		if len(links) != 1 || links[0] != exp {
+			t.Errorf("Expected link %q for %q, got %q", exp, text, links)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]string{
+		":work:":                    {"work"},
+		"text :work:idea: and more": {"work", "idea"},
+		" :a: :b:":                  {"a", "b"},
+		"https://example.com:8080/": nil,
+		"at 10:30:00 today":         nil,
+		"no:tag: here":              nil,
+	}
+	for text, exp := range cases {
+		if tags := p.Tags(text); fmt.Sprint(tags) != fmt.Sprint(exp) {
+			t.Errorf("Expected tags %q in %q, got %q", exp, text, tags)
+		}
+	}
+
+	page, err := p.Parse(strings.NewReader(":a:b:\n= Title =\n:b:c:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags := fmt.Sprint(page.Tags); tags != "[a b c]" {
+		t.Errorf("Expected each tag once, got %v", tags)
+	}
+}
+
+func TestEscapedBrackets(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]Link{
+		`[[foo \[bar\]]]`:         {{Target: "foo [bar].wiki"}},
+		`[[foo|see \[1\]]]`:       {{Target: "foo.wiki", Description: "see [1]"}},
+		`[[\[a\] b|\[c\]]] [[d]]`: {{Target: "[a] b.wiki", Description: "[c]"}, {Target: "d.wiki"}},
+		`[see \[1\]](note)`:       {{Target: "note.md", Description: "see [1]"}},
+		`[a \[b\] c](x.md) [[y]]`: {{Target: "y.wiki"}, {Target: "x.md", Description: "a [b] c"}},
+	}
+	for text, exp := range cases {
+		links := p.ParseLinks(text)
+		if fmt.Sprint(links) != fmt.Sprint(exp) {
+			t.Errorf("Expected links %+v in %q, got %+v", exp, text, links)
+		}
+	}
+}
+
+func TestTrailingPunctuation(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"[x](y.md).":        "y.md",
+		"[x](y.md),":        "y.md",
+		"[x](y.md))":        "y.md",
+		"[x](y).":           "y.md",
+		"[x](y#a), z":       "y.md",
+		"[x](note (1).md)":  "note (1).md",
+		"[x](note (1).md).": "note (1).md",
+	}
+	for text, exp := range cases {
+		if link := p.ParseMarkdownLinks(text); link != exp {
+			t.Errorf("Expected link %v in %q, got %v", exp, text, link)
+		}
+
+		links := p.ParseLinks("see " + text)
+		if len(links) != 1 || links[0].Target != exp || links[0].Description != "x" {
+			t.Errorf("Expected link %v in %q, got %+v", exp, text, links)
+		}
+	}
+}
+
+func TestDefaultExt(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets := func(links []Link) []string {
+		var t []string
+		for _, l := range links {
+			t = append(t, l.Target)
+		}
+		return t
+	}
+
+	text := "[[foo]] [[bar.wiki]] [[baz#a]] [x](qux) [y](quux.wiki)"
+	exp := "[foo.wiki bar.wiki baz.wiki qux.md quux.wiki]"
+	if got := fmt.Sprint(targets(p.ParseLinks(text))); got != exp {
+		t.Errorf("Expected links %v, got %v", exp, got)
+	}
+
+	p.DefaultExt = ".md"
+	exp = "[foo.md bar.wiki baz.md qux.md quux.wiki]"
+	if got := fmt.Sprint(targets(p.ParseLinks(text))); got != exp {
+		t.Errorf("Expected links %v, got %v", exp, got)
+	}
+	if link := p.ParseWikiLinks("[[foo]]"); link != "foo.md" {
+		t.Errorf("Expected a bare wiki link to refer to foo.md, got %v", link)
+	}
+
+	p.DefaultExt = ".wiki"
+	if link := p.ParseMarkdownLinks("[x](qux)"); link != "qux.wiki" {
+		t.Errorf("Expected a bare markdown link to refer to qux.wiki, got %v", link)
+	}
+}
+
+func TestQueryStrings(t *testing.T) {
+	p, err := NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]Link{
+		"[x](page/)":          {Target: "page.md", Description: "x"},
+		"[x](page?x=1)":       {Target: "page.md", Description: "x"},
+		"[x](page#frag?x=1)":  {Target: "page.md", Description: "x", Anchor: "frag"},
+		"[x](a/page.md?x=1)":  {Target: "a/page.md", Description: "x"},
+		"[x](page/?x=1#frag)": {Target: "page.md", Description: "x", Anchor: "frag"},
+	}
+	for text, exp := range cases {
+		if link := p.ParseMarkdownLinks(text); link != exp.Target {
+			t.Errorf("Expected link %v in %q, got %v", exp.Target, text, link)
+		}
+		links := p.ParseLinks(text)
+		if len(links) != 1 || links[0] != exp {
+			t.Errorf("Expected link %+v in %q, got %+v", exp, text, links)
+		}
+	}
+
+	// a query string of an external website is kept
+	if link := p.ParseMarkdownLinks("[x](https://a.com/?q=1)"); link != "https://a.com/?q=1" {
+		t.Errorf("Expected the url as is, got %v", link)
+	}
+	if links := p.ParseLinks("[x](/)"); len(links) != 0 {
+		t.Errorf("Expected no link to the root, got %+v", links)
+	}
+}
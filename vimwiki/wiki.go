@@ -0,0 +1,1462 @@
+package vimwiki
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emicklei/dot"
+)
+
+const wiki_ext string = ".wiki"
+
+// binaryExts are the extensions of files that are known to be binary.
+var binaryExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true,
+	".pdf": true, ".zip": true, ".gz": true, ".mp3": true, ".mp4": true,
+}
+
+// sniffLen is the number of leading bytes inspected to detect binary files.
+const sniffLen = 512
+
+// Range of font sizes of nodes when sizing nodes by their degree.
+const (
+	minFontSize = 10.0
+	maxFontSize = 30.0
+)
+
+// progressInterval is the number of files after which progress is reported.
+var progressInterval = 500
+
+// Wiki builds the graph of links between the files of a vimwiki directory.
+type Wiki struct {
+	// Root directory of vimwiki structure
+	root string
+	// Absolute path of root
+	abs string
+	// Connections from a file to its links
+	graph map[string][]string
+	// Properties of each connection in graph
+	edges map[[2]string]*Edge
+	// First heading of each file
+	titles map[string]string
+	// Files that are not notes, e.g. images
+	assets map[string]bool
+	// URLs of external websites
+	externals map[string]bool
+	// Pages in other wikis, e.g. wiki1:index
+	interwikis map[string]bool
+	// Nodes to emphasise in the output
+	highlight map[string]bool
+	// Original names of nodes without extension
+	names map[string]string
+	// Files by their name, e.g. note.wiki to a/note.wiki and b/note.wiki
+	files map[string][]string
+	// Links between files before renaming
+	resolved map[[2]string]bool
+	// Latest modification time of the files of each node
+	mtimes map[string]time.Time
+	// Links with invalid syntax in each file, before renaming
+	malformed map[string][]Malformed
+	// Errors of the files and directories that could not be added
+	failed map[string]error
+	// Tags of each file, before renaming
+	tags map[string][]string
+	// Nodes whose file only redirects to another note
+	redirects map[string]bool
+	// Word count and headings of the files of each node
+	meta map[string]*meta
+	// Directories to rename during processing
+	remap map[string]string
+	// Labels of the nodes that directories are renamed to, if any
+	labels map[string]string
+	// Enable clustered plotting of files in sub directories
+	cluster bool
+	// When any path matches any of these strings, it is ignored in the
+	// resulting graphs.
+	ignorePaths []string
+
+	// Extracts the links from the text of each file
+	*Parser
+	ignored []*regexp.Regexp
+
+	// Nodes in the graph, including files only present as link targets
+	present map[string]bool
+	// Sorted files linking to each node, built by InLinks
+	inLinks map[string][]string
+	// Whether the graph changed since inLinks was built
+	dirty bool
+
+	// Links of previously parsed files, nil when caching is disabled
+	cache *cache
+	// Number of files added to the graph
+	parsed int
+	// Number of files found, including ignored files
+	walked int
+	// Number of files whose page is taken from the cache
+	cached int
+
+	// Label nodes by the first heading of their file instead of their path
+	Titles bool
+	// Labels of nodes by their path, after renaming, overriding any other
+	// label, e.g. index.wiki to Home
+	Aliases map[string]string
+	// Label edges by the description of their link
+	EdgeLabels bool
+	// Draw an undirected graph, merging reciprocal links into a single edge
+	Undirected bool
+	// Merge reciprocal links into a single edge with arrows on both ends
+	MergeReciprocal bool
+	// Shape nodes by the extension of their file
+	Shapes bool
+	// Include links to files other than notes, e.g. images
+	Assets bool
+	// Draw links in checkbox items, i.e. - [ ] task [[link]], dashed
+	Tasks bool
+	// Color edges by the modification time of their source file, from red
+	// for the oldest to green for the most recent file
+	ColorByAge bool
+	// Include links to external websites
+	Externals bool
+	// Skip links from a file to itself, after renaming
+	NoSelfLoops bool
+	// Add tooltips with the number of links to and from each node
+	Tooltips bool
+	// Add tooltips with the number of words and the headings of each node
+	Meta bool
+	// Size nodes by their number of links to and from other nodes
+	SizeByDegree bool
+	// Draw nodes as tables listing their directory and number of links
+	RecordLabels bool
+	// When positive, draw at most this many outgoing edges per node
+	MaxEdges int
+	// Draw only edges created from at least this many links
+	MinWeight int
+	// Caption of the graph, e.g. the name of the wiki
+	Caption string
+	// Add the time of generation and the number of nodes and edges to the
+	// caption of the graph
+	Metadata bool
+	// Add a legend explaining the styles of the nodes and edges
+	Legend bool
+	// Draw nodes below the level of Dot in grey rather than leaving them out
+	FaintBelowLevel bool
+	// Merge files with the same name, but with a .wiki or .md extension,
+	// into a single node
+	MergeExtensions bool
+	// Links counted against the level of Dot: "out", "in" or, by default,
+	// "total" for both
+	DegreeMode string
+	// Draw each weakly connected component in a separate subgraph, instead
+	// of clustering by directory
+	ClusterComponents bool
+	// Cluster diary entries, i.e. files named 2006-01-02.wiki, by their
+	// "week", "month" or "year", instead of clustering by directory
+	DiaryBy string
+	// Link each node to its file, which GraphViz passes on to SVG output
+	URLs bool
+	// Prefix of the link of each node, e.g. https://example.com/wiki/, which
+	// defaults to a file:// URL of the wiki's directory
+	URLBase string
+	// When not nil, the number of processed files is periodically reported
+	// while walking
+	Progress io.Writer
+	// Skip files that cannot be read while walking rather than aborting
+	ContinueOnError bool
+	// Walk the directories that symbolic links refer to
+	FollowSymlinks bool
+	// Walk files and directories whose name starts with a dot, e.g. .obsidian
+	IncludeHidden bool
+	// When positive, abort walking once the graph has more nodes than this
+	MaxNodes int
+	// Deepest level of directories below the root that is walked, where 0
+	// only walks the root itself, or negative to walk all directories
+	MaxDepth int
+	// Level of detail of the messages logged while walking: LogWarnings,
+	// LogDirs or LogLinks
+	Verbosity int
+	// Destination of the logged messages, stderr when nil
+	Log io.Writer
+}
+
+// WalkErrors holds the errors, per path, of all files that are skipped while
+// walking with wiki.ContinueOnError == true.
+type WalkErrors map[string]error
+
+func (e WalkErrors) Error() string {
+	paths := make([]string, 0, len(e))
+	for path := range e {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	msg := fmt.Sprintf("%d files could not be read", len(e))
+	for _, path := range paths {
+		msg += fmt.Sprintf("\n\t%v: %v", path, e[path])
+	}
+	return msg
+}
+
+// MaxNodesError is returned when walking is aborted because the graph has
+// more than wiki.MaxNodes nodes.
+type MaxNodesError struct {
+	Max int
+}
+
+func (e *MaxNodesError) Error() string {
+	return fmt.Sprintf("graph has more than %d nodes, aborting", e.Max)
+}
+
+// Edge holds the properties of the connection between two files.
+type Edge struct {
+	// Description of the first link from which the edge is created
+	Label string
+	// Whether the edge refers to a file other than a note
+	Asset bool
+	// Number of links from which the edge is created
+	Weight int
+	// Section of the first link that refers to a section in the file
+	Anchor string
+	// Lines of the source file containing the links, in order of appearance
+	Lines []int
+	// Whether any of the links is part of a checkbox item
+	Task bool
+	// Whether any of the links embeds the file, i.e. ![[link]]
+	Embed bool
+	// Whether the edge is left out of the spanning tree of Tree
+	Cross bool
+}
+
+// meta holds a summary of the content of the files of a node.
+type meta struct {
+	// Number of words of all files
+	Words int
+	// Headings of the file of the node, i.e. not of files collapsed into it
+	Headings []string
+}
+
+// String returns the number of words followed by the headings, e.g. as a
+// tooltip.
+func (m *meta) String() string {
+	s := fmt.Sprintf("%d words", m.Words)
+	if len(m.Headings) > 0 {
+		s += ": " + strings.Join(m.Headings, ", ")
+	}
+	return s
+}
+
+// NewWiki returns a Wiki rooted at dir. Paths are renamed according to remap,
+// and any path matching any of the regexes ignore is left out of the graph.
+// Empty regexes are skipped.
+//
+// As in vimwiki links, a renamed path in remap can be followed by the label
+// of its node, e.g. projects to projects.wiki|Projects, which Dot draws
+// instead of the path.
+//
+// A leading ~ and any environment variables in dir are expanded.
+func NewWiki(dir string, remap map[string]string, cluster bool, ignore ...string) (*Wiki, error) {
+	dir, err := ExpandPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// compare clean paths only, e.g. diary/ and ./diary equal diary
+	clean := make(map[string]string, len(remap))
+	labels := make(map[string]string)
+	for k, v := range remap {
+		target, label := v, ""
+		if idx := strings.Index(v, "|"); idx >= 0 {
+			target, label = v[:idx], v[idx+1:]
+		}
+		target = path.Clean(filepath.ToSlash(target))
+		clean[path.Clean(filepath.ToSlash(k))] = target
+		if label != "" {
+			labels[target] = label
+		}
+	}
+
+	wiki := Wiki{
+		root:        dir,
+		abs:         abs,
+		remap:       clean,
+		labels:      labels,
+		graph:       make(map[string][]string),
+		edges:       make(map[[2]string]*Edge),
+		titles:      make(map[string]string),
+		assets:      make(map[string]bool),
+		externals:   make(map[string]bool),
+		interwikis:  make(map[string]bool),
+		names:       make(map[string]string),
+		files:       make(map[string][]string),
+		resolved:    make(map[[2]string]bool),
+		mtimes:      make(map[string]time.Time),
+		malformed:   make(map[string][]Malformed),
+		failed:      make(map[string]error),
+		tags:        make(map[string][]string),
+		redirects:   make(map[string]bool),
+		meta:        make(map[string]*meta),
+		ignorePaths: ignore,
+		cluster:     cluster,
+		MaxDepth:    -1,
+	}
+	err = wiki.CompileExpressions()
+	return &wiki, err
+}
+
+// ExpandPath replaces a leading ~ in path by the home directory of the
+// current user and expands any environment variables, e.g. $WIKI_HOME.
+func ExpandPath(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// Walk walks over all directories in wiki.root except for any directory
+// contained in subDirToSkip.
+//
+// Symbolic links to directories are skipped, unless wiki.FollowSymlinks ==
+// true. Links to directories inside wiki.root, or to directories that are
+// already followed, are always skipped to prevent walking files twice.
+// Files and directories whose name starts with a dot, e.g. swap files of
+// editors, are skipped unless wiki.IncludeHidden == true.
+//
+// If wiki.ContinueOnError == true, files and directories that cannot be read
+// are skipped and their errors are returned as WalkErrors once done. If
+// wiki.MaxNodes > 0, walking is aborted with a *MaxNodesError as soon as the
+// graph has more than wiki.MaxNodes nodes. If wiki.MaxDepth >= 0, directories
+// more than wiki.MaxDepth levels below wiki.root are skipped.
+func (wiki *Wiki) Walk(subDirToSkip []string) error {
+	return wiki.WalkContext(context.Background(), subDirToSkip)
+}
+
+// WalkContext walks wiki.root as Walk, but stops as soon as ctx is done, in
+// which case ctx.Err() is returned. Any files added before are kept.
+func (wiki *Wiki) WalkContext(ctx context.Context, subDirToSkip []string) error {
+	errs := make(WalkErrors)
+
+	// real paths of the walked directories, i.e. after resolving symlinks
+	var followed []string
+	if wiki.FollowSymlinks {
+		root, err := realPath(wiki.root)
+		if err != nil {
+			return err
+		}
+		followed = append(followed, root)
+	}
+
+	// walk walks the directory real, which is found at dir inside wiki.root
+	var walk func(dir, real string) error
+	walk = func(dir, real string) error {
+		return filepath.Walk(real, func(path string, info os.FileInfo, err error) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(real, path)
+			path = filepath.Join(dir, rel)
+			if err != nil {
+				return wiki.skip(errs, path, err)
+			}
+			if !wiki.IncludeHidden && rel != "." && strings.HasPrefix(info.Name(), ".") {
+				wiki.logf(LogDirs, "skipping hidden: %v", path)
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Mode()&os.ModeSymlink != 0 {
+				target, err := realPath(path)
+				if err != nil {
+					return wiki.skip(errs, path, err)
+				}
+				if info, err := os.Stat(target); err == nil && info.IsDir() {
+					if !wiki.FollowSymlinks || inAny(target, followed) {
+						wiki.logf(LogDirs, "skipping symlink: %v", path)
+						return nil
+					}
+					wiki.logf(LogDirs, "following symlink: %v -> %v", path, target)
+					followed = append(followed, target)
+					return walk(path, target)
+				}
+			}
+			if info.IsDir() {
+				for _, s := range subDirToSkip {
+					if info.Name() == s {
+						wiki.logf(LogDirs, "skipping: %v", path)
+						return filepath.SkipDir
+					}
+				}
+				if wiki.MaxDepth >= 0 && depth(wiki.root, path) > wiki.MaxDepth {
+					wiki.logf(LogDirs, "skipping beyond max depth: %v", path)
+					return filepath.SkipDir
+				}
+				wiki.logf(LogDirs, "entering: %v", path)
+				return nil
+			}
+			if err := wiki.visit(path); err != nil {
+				return wiki.skip(errs, path, err)
+			}
+			return nil
+		})
+	}
+	if err := walk(wiki.root, wiki.root); err != nil {
+		return err
+	}
+	return wiki.done(errs)
+}
+
+// depth returns the number of directories between root and the directory at
+// path, i.e. 0 for root itself and 1 for its subdirectories.
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// Counts holds the number of files processed while building the graph.
+type Counts struct {
+	// Files found by Walk or given to AddFiles, including ignored files
+	Walked int
+	// Files added to the graph
+	Added int
+	// Files whose links are taken from the cache rather than parsed
+	Cached int
+}
+
+// Counts returns the number of files processed so far.
+func (wiki *Wiki) Counts() Counts {
+	return Counts{Walked: wiki.walked, Added: wiki.parsed, Cached: wiki.cached}
+}
+
+// Errors returns the error of each file or directory that could not be added
+// while walking, e.g. because it cannot be read. Unless wiki.ContinueOnError
+// == true, walking stops at the first such error.
+func (wiki *Wiki) Errors() map[string]error {
+	errs := make(map[string]error, len(wiki.failed))
+	for path, err := range wiki.failed {
+		errs[path] = err
+	}
+	return errs
+}
+
+// realPath returns the absolute path of path after resolving any symbolic
+// links.
+func realPath(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(real)
+}
+
+// inAny returns true when path equals or is contained in any of dirs.
+func inAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFiles adds exactly the files at paths to the graph, rather than walking
+// all directories in wiki.root. Paths are either absolute or relative to the
+// current directory, and must refer to files inside wiki.root.
+//
+// Errors are handled as in Walk.
+func (wiki *Wiki) AddFiles(paths []string) error {
+	errs := make(WalkErrors)
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(wiki.abs, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			err = fmt.Errorf("%v is not inside %v", path, wiki.root)
+			if err := wiki.skip(errs, path, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// paths are relative to wiki.root when added
+		if err := wiki.visit(filepath.Join(wiki.root, rel)); err != nil {
+			if err := wiki.skip(errs, path, err); err != nil {
+				return err
+			}
+		}
+	}
+	return wiki.done(errs)
+}
+
+// skip records err for path in errs when wiki.ContinueOnError == true.
+// Otherwise, or if the graph has too many nodes, err is returned as is. Any
+// error of path is kept for Errors.
+func (wiki *Wiki) skip(errs WalkErrors, path string, err error) error {
+	if _, ok := err.(*MaxNodesError); ok {
+		return err
+	}
+	if wiki.failed != nil {
+		wiki.failed[path] = err
+	}
+	if !wiki.ContinueOnError {
+		return err
+	}
+	wiki.logf(LogWarnings, "skipping %v: %v", path, err)
+	errs[path] = err
+	return nil
+}
+
+// visit adds the file at path, unless it is ignored, and reports progress.
+func (wiki *Wiki) visit(path string) error {
+	wiki.walked++
+
+	// match the same path as the links to the file
+	rel, err := filepath.Rel(wiki.root, path)
+	if err != nil {
+		rel = path
+	}
+	if wiki.IgnorePath(filepath.ToSlash(rel)) {
+		return nil
+	}
+	parsed := wiki.parsed
+	if err := wiki.Add(path); err != nil {
+		return err
+	}
+	if wiki.MaxNodes > 0 && len(wiki.present) > wiki.MaxNodes {
+		return &MaxNodesError{wiki.MaxNodes}
+	}
+
+	// only report once for each interval of files that are parsed
+	if wiki.Progress != nil && wiki.parsed != parsed && wiki.parsed%progressInterval == 0 {
+		fmt.Fprintf(wiki.Progress, "processed %d files\n", wiki.parsed)
+	}
+	return nil
+}
+
+// done reports the total number of processed files and saves the cache once
+// all files are added. Failing to save the cache is only logged as a warning.
+// Any skipped files are returned as WalkErrors.
+func (wiki *Wiki) done(errs WalkErrors) error {
+	if wiki.Progress != nil {
+		fmt.Fprintf(wiki.Progress, "processed %d files in total\n", wiki.parsed)
+	}
+	if wiki.cache != nil {
+		if err := wiki.cache.save(); err != nil {
+			wiki.logf(LogWarnings, "not saving cache: %v", err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// EnableCache loads the cache of previously extracted links from dir. Files
+// that are unchanged since they have been cached are not parsed again.
+func (wiki *Wiki) EnableCache(dir string) error {
+	c, err := loadCache(dir, wiki.root)
+	if err != nil {
+		return err
+	}
+	wiki.cache = c
+	return nil
+}
+
+func (wiki *Wiki) Insert(key, value string) {
+	// prevent (possibly many) duplicates
+	if unique(value, wiki.graph[key]) {
+		wiki.graph[key] = append(wiki.graph[key], value)
+		wiki.addNode(key)
+		wiki.addNode(value)
+		wiki.dirty = true
+	}
+}
+
+// addNode records that node is present in the graph.
+func (wiki *Wiki) addNode(node string) {
+	if wiki.present == nil {
+		wiki.present = make(map[string]bool)
+	}
+	wiki.present[node] = true
+}
+
+// edge returns the properties of the connection from key to value.
+func (wiki *Wiki) edge(key, value string) *Edge {
+	e, ok := wiki.edges[[2]string{key, value}]
+	if !ok {
+		e = &Edge{}
+		wiki.edges[[2]string{key, value}] = e
+	}
+	return e
+}
+
+// Remap resolves the link match found in file key inside directory dir and
+// renames both according to wiki.remap. Links are relative to dir, unless
+// they start with a slash which makes them relative to wiki.root instead.
+// Following vimwiki, links starting with two slashes are absolute paths on
+// the file system, which are made relative to wiki.root when inside it.
+func (wiki *Wiki) Remap(dir, key, match string) (string, string) {
+
+	dir = filepath.ToSlash(dir)
+	match = wiki.resolve(dir, match)
+
+	// apply remap naming, diary/file.wiki -> diary.wiki
+	for k, v := range wiki.remap {
+		if inDir(dir, k) {
+			key = v
+		}
+	}
+
+	return path.Clean(key), wiki.rename(match)
+}
+
+// rename returns the name of the node of the file at p, i.e. the clean path
+// p renamed according to wiki.remap, e.g. diary/file.wiki -> diary.wiki.
+func (wiki *Wiki) rename(p string) string {
+	for k, v := range wiki.remap {
+		if inDir(p, k) {
+			return path.Clean(v)
+		}
+	}
+	return path.Clean(p)
+}
+
+// resolve joins the link match found inside directory dir with dir, or with
+// wiki.root for links as [[/index]].
+func (wiki *Wiki) resolve(dir, match string) string {
+	match = filepath.ToSlash(match)
+	switch {
+	case strings.HasPrefix(match, "//"):
+		return wiki.absolute(match[1:])
+	case strings.HasPrefix(match, "/"):
+		return path.Clean(match[1:])
+	default:
+		return path.Join(filepath.ToSlash(dir), match)
+	}
+}
+
+// absolute returns the path relative to wiki.root of the absolute path abs,
+// or abs itself if it is outside of wiki.root.
+func (wiki *Wiki) absolute(abs string) string {
+	abs = path.Clean(abs)
+	if wiki.abs == "" {
+		return abs
+	}
+	rel, err := filepath.Rel(wiki.abs, filepath.FromSlash(abs))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return filepath.ToSlash(rel)
+}
+
+// inDir returns true when path equals dir or is contained in dir. Only
+// complete path components are compared, i.e. diary-old is not in diary.
+func inDir(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// Compile compiles all regex to match links with
+func (wiki *Wiki) CompileExpressions() error {
+	parser, err := NewParser()
+	if err != nil {
+		return err
+	}
+	wiki.Parser = parser
+
+	wiki.ignored = nil
+	for _, expr := range wiki.ignorePaths {
+		if expr == "" {
+			continue
+		}
+		ignored, err := regexp.Compile(expr)
+		if err != nil {
+			return err
+		}
+		wiki.ignored = append(wiki.ignored, ignored)
+	}
+
+	return nil
+}
+
+// IgnorePath returns true when path matches any of the regexes to ignore.
+// Files and links are matched by their path relative to the wiki's directory
+// before renaming, e.g. archive/note.wiki, such that ^archive/ ignores both
+// the files in archive and all links to them.
+func (wiki *Wiki) IgnorePath(path string) bool {
+	// Return true if any match with any of the given regexes is observed, in
+	// that case the link should not be added to the graph. When no regexes
+	// are provided to be ignored, always accept the files
+	for _, ignored := range wiki.ignored {
+		if ignored.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds path to the wiki.graph when it contains links to other files.
+//
+// Only the relative paths are considered between the passed path and wiki.root,
+// which always use forward slashes as separator.
+func (wiki *Wiki) Add(path string) error {
+	key, err := filepath.Rel(wiki.root, path)
+	if err != nil {
+		return err
+	}
+	key = filepath.ToSlash(key)
+	dir := filepath.Dir(key) // current dir when in subdirectory
+	file := key              // key before renaming
+	key = wiki.mergeExtension(wiki.rename(key), true)
+	renamed := wiki.rename(file) != file
+
+	page, err := wiki.ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	// binary files are only present as assets
+	if page.Binary && !wiki.Assets {
+		return nil
+	}
+
+	// initialise a node
+	if _, ok := wiki.graph[key]; !ok {
+		wiki.graph[key] = make([]string, 0)
+		wiki.addNode(key)
+	}
+
+	// index files by name to detect ambiguous links
+	name := filepath.Base(file)
+	wiki.files[name] = append(wiki.files[name], file)
+
+	// files that are collapsed into another node, e.g. diary.wiki, do not
+	// determine its properties
+	if page.Binary {
+		if !renamed {
+			wiki.assets[key] = true
+		}
+		return nil
+	}
+	wiki.parsed++
+	if page.Title != "" && !renamed {
+		wiki.titles[key] = page.Title
+	}
+
+	// collapsed nodes are as recent as their most recent file
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().After(wiki.mtimes[key]) {
+		wiki.mtimes[key] = info.ModTime()
+	}
+
+	// collapsed nodes count the words of all their files
+	m, ok := wiki.meta[key]
+	if !ok {
+		m = &meta{}
+		wiki.meta[key] = m
+	}
+	m.Words += page.Words
+	if !renamed {
+		m.Headings = page.Headings
+	}
+
+	if len(page.Malformed) > 0 {
+		wiki.malformed[file] = page.Malformed
+	}
+	if len(page.Tags) > 0 {
+		wiki.tags[file] = page.Tags
+	}
+	// a collapsed node is more than a redirect
+	if page.Redirect && !renamed {
+		wiki.redirects[key] = true
+	}
+
+	for _, l := range page.Links {
+		wiki.logf(LogLinks, "%v:%d: link to %v", file, l.Line, l.Target)
+
+		// do not insert links to ignored paths, matching the path of the
+		// file that is linked to rather than the link itself
+		target := l.Target
+		if !l.External && !l.InterWiki {
+			target = wiki.resolve(dir, l.Target)
+		}
+		if wiki.IgnorePath(target) {
+			continue
+		}
+		if l.Asset && !wiki.Assets {
+			continue
+		}
+		if l.External && !wiki.Externals {
+			continue
+		}
+
+		if !l.External && !l.Asset && !l.InterWiki {
+			wiki.resolved[[2]string{file, wiki.resolve(dir, l.Target)}] = true
+		}
+
+		// rename and/or collapse folders, URLs and pages in other wikis are
+		// kept as is
+		var link string
+		key, link = wiki.Remap(dir, key, l.Target)
+		if l.External {
+			link = l.Target
+			wiki.externals[link] = true
+		} else if l.InterWiki {
+			link = l.Target
+			wiki.interwikis[link] = true
+		} else {
+			key = wiki.mergeExtension(key, false)
+			link = wiki.mergeExtension(link, false)
+		}
+		wiki.logf(LogLinks, "%v:%d: renamed to %v -> %v", file, l.Line, key, link)
+
+		// only after renaming it is known whether a link refers to the
+		// file itself, e.g. between two collapsed diary entries
+		if wiki.NoSelfLoops && key == link {
+			continue
+		}
+
+		// insert into the graph
+		wiki.Insert(key, link)
+
+		// keep the first description of the link
+		edge := wiki.edge(key, link)
+		edge.Weight++
+		if edge.Label == "" {
+			edge.Label = l.Description
+		}
+		if edge.Anchor == "" {
+			edge.Anchor = l.Anchor
+		}
+		if l.Line > 0 {
+			edge.Lines = append(edge.Lines, l.Line)
+		}
+		if l.Task {
+			edge.Task = true
+		}
+		if l.Embed {
+			edge.Embed = true
+		}
+		if l.Asset {
+			edge.Asset = true
+			wiki.assets[link] = true
+		}
+	}
+	return nil
+}
+
+// ageColor returns the color of a file modified at t, on a gradient from red
+// at oldest to green at newest, as a HSV color. Files are considered recent
+// when all files share the same modification time.
+func ageColor(t, oldest, newest time.Time) string {
+	f := 1.0
+	if span := newest.Sub(oldest); span > 0 {
+		f = float64(t.Sub(oldest)) / float64(span)
+	}
+	return fmt.Sprintf("%.3f 1.000 0.800", f/3)
+}
+
+// mergeExtension returns path without its .wiki or .md extension if
+// wiki.MergeExtensions == true, such that links to note.wiki and note.md
+// refer to the same node. The original path is kept as the name of the
+// node, preferring the name of an existing file over that of a link.
+func (wiki *Wiki) mergeExtension(path string, file bool) string {
+	ext := filepath.Ext(path)
+	if !wiki.MergeExtensions || (ext != ".wiki" && ext != ".md") {
+		return path
+	}
+
+	node := strings.TrimSuffix(path, ext)
+	if _, ok := wiki.names[node]; file || !ok {
+		wiki.names[node] = path
+	}
+	return node
+}
+
+// ParseFile returns the page of the file at path. When caching is enabled,
+// the page is taken from the cache if the file did not change since.
+//
+// Binary files, detected by their extension or by null bytes in their first
+// bytes, are not searched for links.
+func (wiki *Wiki) ParseFile(path string) (Page, error) {
+	var info os.FileInfo
+	if wiki.cache != nil {
+		var err error
+		info, err = os.Stat(path)
+		if err != nil {
+			return Page{}, err
+		}
+		if page, ok := wiki.cache.get(path, info.ModTime(), wiki.options()); ok {
+			wiki.cached++
+			return page, nil
+		}
+	}
+
+	page, err := wiki.parseFile(path)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if wiki.cache != nil {
+		wiki.cache.put(path, info.ModTime(), wiki.options(), page)
+	}
+	return page, nil
+}
+
+func (wiki *Wiki) parseFile(path string) (Page, error) {
+	if binaryExts[strings.ToLower(filepath.Ext(path))] {
+		return Page{Binary: true}, nil
+	}
+
+	// open file to find links
+	file, err := os.Open(path)
+	if err != nil {
+		return Page{}, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	head, err := r.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return Page{}, err
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return Page{Binary: true}, nil
+	}
+	return wiki.Parse(r)
+}
+
+// degree returns the number of links of path counted against the level of
+// Dot, i.e. its outgoing, incoming or total links given wiki.DegreeMode.
+func (wiki *Wiki) degree(path string, out, in map[string]int) int {
+	switch wiki.DegreeMode {
+	case "out":
+		return out[path]
+	case "in":
+		return in[path]
+	default:
+		return out[path] + in[path]
+	}
+}
+
+// Dot converts wiki.graph into dot.Graph.
+//
+// Only nodes, and their connections, are drawn if their sum of edges
+// is greater than the provided level. For `level = 0` all nodes
+// are inserted. Both outgoing and incoming links are counted, unless
+// wiki.DegreeMode is "out" or "in". If wiki.FaintBelowLevel == true the
+// remaining nodes are drawn in grey, with dashed edges, instead of being
+// left out.
+//
+// If wiki.cluster == true any nodes that correspond to a subdirectory are
+// inserted in the corresponding subgraph of that subdirectory. By default, the
+// visualisation will highlight these subgraphs. If wiki.ClusterComponents ==
+// true the nodes are instead inserted in a subgraph per weakly connected
+// component, see Components. If wiki.DiaryBy is set, diary entries are
+// inserted in a subgraph per week, month or year instead.
+//
+// If wiki.Titles == true nodes are labelled by the first heading of their
+// file, when available, rather than by their path. Similarly, edges are
+// labelled by the description of their link if wiki.EdgeLabels == true. Nodes
+// of renamed directories are labelled by the label in their remap, if any,
+// and nodes in wiki.Aliases are always labelled by their alias.
+//
+// If wiki.MergeExtensions == true files with the same name, but with a .wiki
+// or .md extension, are drawn as a single node labelled by the name of the
+// existing file.
+//
+// If wiki.Shapes == true nodes are shaped by the extension of their file.
+// Files other than notes, only present if wiki.Assets == true, are filled
+// and connected by dotted edges. External websites, only present if
+// wiki.Externals == true, are grouped in a separate "external" subgraph.
+// Similarly, pages in other wikis, i.e. [[wiki1:page]], are dashed and grouped
+// in a subgraph per wiki.
+//
+// If wiki.URLs == true each node links to its file, prefixed by
+// wiki.URLBase, such that clicking a node in SVG output opens the file.
+//
+// If wiki.Tasks == true links in checkbox items, i.e. - [ ] task [[link]],
+// are drawn as dashed edges. Edges of embedded files, i.e. ![[link]], are
+// always drawn bold.
+//
+// If wiki.ColorByAge == true edges are colored by the modification time of
+// their source file, from red for the oldest to green for the most recent.
+//
+// If wiki.Tooltips == true each node gets a tooltip with its number of
+// outgoing and incoming links, and each edge with the lines of its links in
+// the source file, which GraphViz passes on to SVG output.
+//
+// If wiki.Meta == true each node gets a tooltip with the number of words and
+// the headings of its files, after any tooltip of its links.
+//
+// If wiki.SizeByDegree == true the font size of each node scales with its
+// total number of links, between minFontSize and maxFontSize.
+//
+// If wiki.RecordLabels == true nodes are drawn as tables listing their name,
+// directory and number of outgoing links, replacing any shape.
+//
+// If wiki.MaxEdges > 0 only the wiki.MaxEdges most frequent links of each
+// node are drawn, while the remaining links are collapsed into a single node.
+// If wiki.MinWeight > 1 edges created from fewer links are left out before
+// counting the edges of each node against level.
+//
+// If wiki.Caption is set, or wiki.Metadata == true, the graph is labelled by
+// its caption, optionally followed by the time of generation and the number
+// of drawn nodes and edges. If wiki.Legend == true a separate cluster explains
+// the styles of the nodes and edges.
+//
+// If wiki.Undirected == true the graph is undirected and a link in either
+// direction between two nodes results in a single edge. Otherwise, if
+// wiki.MergeReciprocal == true, two files linking to each other are connected
+// by a single blue edge with arrows on both ends.
+func (wiki *Wiki) Dot(level int, opts ...dot.GraphOption) *dot.Graph {
+	if wiki.MinWeight > 1 {
+		c := wiki.clone()
+		c.removeWeakLinks(wiki.MinWeight)
+		c.MinWeight = 0
+		return c.Dot(level, opts...)
+	}
+
+	graph := dot.NewGraph()
+	for _, opt := range opts {
+		opt.Apply(graph)
+	}
+	if wiki.Undirected {
+		dot.Undirected.Apply(graph)
+	}
+
+	out, in := wiki.degrees()
+	var maxDegree int
+	if wiki.SizeByDegree {
+		for _, n := range wiki.nodes() {
+			if d := out[n] + in[n]; d > maxDegree {
+				maxDegree = d
+			}
+		}
+	}
+	degree := func(path string) int {
+		return wiki.degree(path, out, in)
+	}
+	var oldest, newest time.Time
+	if wiki.ColorByAge {
+		for _, t := range wiki.mtimes {
+			if oldest.IsZero() || t.Before(oldest) {
+				oldest = t
+			}
+			if t.After(newest) {
+				newest = t
+			}
+		}
+	}
+	component := make(map[string]int)
+	if wiki.ClusterComponents {
+		for i, c := range wiki.Components() {
+			for _, n := range c {
+				component[n] = i + 1
+			}
+		}
+	}
+	node := func(path string) dot.Node {
+		parent := graph
+		if wiki.ClusterComponents {
+			title := fmt.Sprintf("component %d", component[path])
+			parent = graph.Subgraph(title, dot.ClusterOption{})
+		}
+		n := wiki.node(parent, path)
+		var tooltip []string
+		if wiki.Tooltips {
+			tooltip = append(tooltip, fmt.Sprintf("%d outgoing, %d incoming links", out[path], in[path]))
+		}
+		if m, ok := wiki.meta[path]; wiki.Meta && ok {
+			tooltip = append(tooltip, m.String())
+		}
+		if len(tooltip) > 0 {
+			n.Attr("tooltip", quote(strings.Join(tooltip, "; ")))
+		}
+		if wiki.SizeByDegree && maxDegree > 0 {
+			d := float64(out[path]+in[path]) / float64(maxDegree)
+			n.Attr("fontsize", fmt.Sprintf("%.1f", minFontSize+d*(maxFontSize-minFontSize)))
+		}
+		if wiki.RecordLabels {
+			n.Attr("shape", "plaintext")
+			n.Attr("label", recordLabel(wiki.label(path), path, out[path]))
+		}
+		return n
+	}
+
+	edges := 0
+	for k, val := range wiki.graph {
+
+		// skip nodes with less edges, or draw them faintly
+		faint := degree(k) < level
+		if faint && !wiki.FaintBelowLevel {
+			continue
+		}
+
+		a := node(k)
+		if faint && !wiki.highlight[k] {
+			a.Attr("color", "grey")
+			a.Attr("fontcolor", "grey")
+		}
+		if wiki.MaxEdges > 0 && len(val) > wiki.MaxEdges {
+			val = wiki.strongest(k, wiki.MaxEdges)
+
+			// collapse the remaining edges into a single node
+			more := fmt.Sprintf("…+%d more", len(wiki.graph[k])-wiki.MaxEdges)
+			m := graph.Node(k+" "+more).Label(more).Attr("shape", "plaintext")
+			graph.Edge(a, m).Attr("style", "dashed")
+			edges++
+		}
+		for _, v := range val {
+			b := node(v)
+
+			// only insert unique edges, ignoring their direction for
+			// undirected graphs and merged reciprocal links
+			reciprocal := wiki.MergeReciprocal && k != v && !unique(k, wiki.graph[v])
+			if len(graph.FindEdges(a, b)) > 0 {
+				continue
+			}
+			if (wiki.Undirected || reciprocal) && len(graph.FindEdges(b, a)) > 0 {
+				continue
+			}
+
+			e := graph.Edge(a, b)
+			edges++
+			if reciprocal && !wiki.Undirected {
+				e.Attr("dir", "both")
+				e.Attr("color", "blue")
+			}
+			if edge, ok := wiki.edges[[2]string{k, v}]; ok {
+				if wiki.EdgeLabels && edge.Label != "" {
+					e.Attr("label", quote(edge.Label))
+				}
+				if edge.Asset {
+					e.Attr("style", "dotted")
+				}
+				if wiki.Tasks && edge.Task {
+					e.Attr("style", "dashed")
+				}
+				if edge.Embed {
+					e.Attr("style", "bold")
+				}
+				if edge.Cross {
+					e.Attr("style", "dashed")
+					e.Attr("constraint", "false")
+				}
+				if wiki.Tooltips && len(edge.Lines) > 0 {
+					e.Attr("tooltip", tooltipLines(edge.Lines))
+				}
+			}
+			if t, ok := wiki.mtimes[k]; ok && wiki.ColorByAge {
+				e.Attr("color", ageColor(t, oldest, newest))
+			}
+			if faint {
+				e.Attr("style", "dashed")
+				e.Attr("color", "grey")
+			}
+		}
+	}
+
+	if wiki.Caption != "" || wiki.Metadata {
+		graph.Attr("label", quote(wiki.caption(len(graph.FindNodes()), edges)))
+		graph.Attr("labelloc", "t")
+	}
+	if wiki.Legend {
+		wiki.legend(graph)
+	}
+	return graph
+}
+
+// legend adds a separate cluster to graph explaining the styles of nodes and
+// edges that may appear in the output of Dot, given the options of wiki.
+func (wiki *Wiki) legend(graph *dot.Graph) {
+	legend := graph.Subgraph("legend", dot.ClusterOption{})
+	count := 0
+	node := func(label string, attrs ...string) {
+		count++
+		n := legend.Node(fmt.Sprintf("legend %d", count)).Label(label)
+		for i := 0; i+1 < len(attrs); i += 2 {
+			n.Attr(attrs[i], attrs[i+1])
+		}
+	}
+	edge := func(label string, attrs ...string) {
+		count++
+		a := legend.Node(fmt.Sprintf("legend %d", count)).Label(label)
+		a.Attr("shape", "plaintext")
+		b := legend.Node(fmt.Sprintf("legend %d target", count)).Label("")
+		b.Attr("shape", "point")
+		e := legend.Edge(a, b)
+		for i := 0; i+1 < len(attrs); i += 2 {
+			e.Attr(attrs[i], attrs[i+1])
+		}
+	}
+
+	if wiki.Shapes && !wiki.RecordLabels {
+		node("vimwiki note", "shape", "box")
+		node("markdown note", "shape", "ellipse")
+		node("other file", "shape", "hexagon")
+	} else {
+		node("note")
+	}
+	if wiki.Assets {
+		node("asset, e.g. an image", "style", "filled", "fillcolor", "lightgrey")
+		edge("link to asset", "style", "dotted")
+	}
+	if wiki.Externals {
+		node("external website", "shape", "box", "style", "rounded,dashed", "color", "darkgreen")
+	}
+	if len(wiki.interwikis) > 0 {
+		node("page in other wiki", "style", "dashed")
+	}
+	if len(wiki.highlight) > 0 {
+		node("highlighted", "color", "red", "fontcolor", "red", "penwidth", "3")
+	}
+	if wiki.FaintBelowLevel {
+		node("below level", "color", "grey", "fontcolor", "grey")
+		edge("link of node below level", "style", "dashed", "color", "grey")
+	}
+	edge("link")
+	if wiki.Tasks {
+		edge("link in task", "style", "dashed")
+	}
+	edge("embedded note", "style", "bold")
+	if wiki.MergeReciprocal && !wiki.Undirected {
+		edge("links in both directions", "dir", "both", "color", "blue")
+	}
+	if wiki.ColorByAge {
+		oldest, newest := time.Unix(0, 0), time.Now()
+		edge("from recently changed file", "color", ageColor(newest, oldest, newest))
+		edge("from oldest file", "color", ageColor(oldest, oldest, newest))
+	}
+}
+
+// caption returns the label of a graph with the given number of nodes and
+// edges, i.e. wiki.Caption followed by the metadata if wiki.Metadata == true.
+func (wiki *Wiki) caption(nodes, edges int) string {
+	var lines []string
+	if wiki.Caption != "" {
+		lines = append(lines, wiki.Caption)
+	}
+	if wiki.Metadata {
+		lines = append(lines, fmt.Sprintf("generated %v, %d nodes, %d edges",
+			time.Now().Format("2006-01-02 15:04"), nodes, edges))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tooltipLines returns the tooltip of an edge created from links on the
+// given lines, e.g. "lines 3, 7".
+func tooltipLines(lines []int) string {
+	s := make([]string, len(lines))
+	for i, l := range lines {
+		s[i] = fmt.Sprint(l)
+	}
+	if len(lines) == 1 {
+		return "line " + s[0]
+	}
+	return "lines " + strings.Join(s, ", ")
+}
+
+// strongest returns the n links of key with the highest weight, i.e. the
+// links that occur most often, in their original order for equal weights.
+func (wiki *Wiki) strongest(key string, n int) []string {
+	links := append([]string{}, wiki.graph[key]...)
+	weight := func(v string) int {
+		if e, ok := wiki.edges[[2]string{key, v}]; ok {
+			return e.Weight
+		}
+		return 0
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		return weight(links[i]) > weight(links[j])
+	})
+	return links[:n]
+}
+
+// node returns the node of path in graph. If wiki.cluster == true and path is
+// in a subdirectory, the node is inserted in the subgraph of that directory.
+// Pages in other wikis are always inserted in the subgraph of their wiki, and
+// diary entries in the subgraph of their period if wiki.DiaryBy is set.
+func (wiki *Wiki) node(graph *dot.Graph, path string) dot.Node {
+	var n dot.Node
+	dir, _ := filepath.Split(path)
+	if wiki.externals[path] {
+		subgraph := graph.Subgraph("external", dot.ClusterOption{})
+		n = subgraph.Node(path)
+		n.Attr("shape", "box")
+		n.Attr("style", "rounded,dashed")
+		n.Attr("color", "darkgreen")
+	} else if wiki.interwikis[path] {
+		name := path[:strings.Index(path, ":")]
+		subgraph := graph.Subgraph(name, dot.ClusterOption{})
+		subgraph.Attr("label", quote(name))
+		n = subgraph.Node(path)
+		n.Attr("style", "dashed")
+	} else if p, ok := wiki.period(path); ok {
+		subgraph := graph.Subgraph(p, dot.ClusterOption{})
+		n = subgraph.Node(path)
+	} else if wiki.cluster && !wiki.ClusterComponents && dir != "" {
+		subgraph := graph.Subgraph(dir, dot.ClusterOption{})
+		subgraph.Attr("label", quote(dir))
+		n = subgraph.Node(path)
+	} else {
+		n = graph.Node(path)
+	}
+
+	name := path
+	if original, ok := wiki.names[path]; ok {
+		name = original
+	}
+	n.Attr("label", quote(wiki.label(path)))
+	if wiki.Shapes && !wiki.externals[path] {
+		n.Attr("shape", shape(name))
+	}
+	if wiki.assets[path] {
+		n.Attr("style", "filled")
+		n.Attr("fillcolor", "lightgrey")
+	}
+	if wiki.URLs && !wiki.interwikis[path] {
+		n.Attr("URL", wiki.url(name))
+	}
+	if wiki.highlight[path] {
+		n.Attr("color", "red")
+		n.Attr("fontcolor", "red")
+		n.Attr("penwidth", "3")
+	}
+	return n
+}
+
+// period returns the week, month or year, according to wiki.DiaryBy, in which
+// the diary entry at path is dated, e.g. 2006-W01, 2006-01 or 2006. False is
+// returned when path is not a diary entry or wiki.DiaryBy is not set.
+func (wiki *Wiki) period(path string) (string, bool) {
+	date, ok := dateOf(path)
+	if !ok {
+		return "", false
+	}
+	switch wiki.DiaryBy {
+	case "week":
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), true
+	case "month":
+		return date.Format("2006-01"), true
+	case "year":
+		return date.Format("2006"), true
+	}
+	return "", false
+}
+
+// url returns the URL of the file at path, relative to wiki.URLBase, or the
+// URL itself for external websites.
+func (wiki *Wiki) url(path string) string {
+	if wiki.externals[path] {
+		return path
+	}
+	base := wiki.URLBase
+	if base == "" {
+		base = "file://" + escapePath(filepath.ToSlash(wiki.abs)) + "/"
+	}
+	return base + escapePath(path)
+}
+
+// escapePath escapes each component of the slash separated path for use in
+// a URL, e.g. replacing spaces by %20.
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// shape returns the node shape for path based on its extension: boxes for
+// vimwiki files, ellipses for markdown files and hexagons for anything else.
+func shape(path string) string {
+	switch filepath.Ext(path) {
+	case ".wiki":
+		return "box"
+	case ".md":
+		return "ellipse"
+	default:
+		return "hexagon"
+	}
+}
+
+// Highlight emphasises the given nodes in the output of Dot. An error is
+// returned if any of the nodes is not present in the graph.
+func (wiki *Wiki) Highlight(nodes ...string) error {
+	present := make(map[string]bool)
+	for _, n := range wiki.nodes() {
+		present[n] = true
+	}
+
+	highlight := make(map[string]bool)
+	for _, n := range nodes {
+		if !present[n] {
+			return fmt.Errorf("cannot highlight %v: node not in graph", n)
+		}
+		highlight[n] = true
+	}
+	wiki.highlight = highlight
+	return nil
+}
+
+// recordLabel returns a HTML-like label showing name, the directory of file
+// and the number of outgoing links in separate rows of a table.
+func recordLabel(name, file string, out int) dot.HTML {
+	return dot.HTML(fmt.Sprintf(
+		`<table border="0" cellborder="1" cellspacing="0">`+
+			`<tr><td><b>%s</b></td></tr>`+
+			`<tr><td>%s</td></tr>`+
+			`<tr><td>%d outgoing links</td></tr>`+
+			`</table>`,
+		html.EscapeString(name), html.EscapeString(path.Dir(file)), out))
+}
+
+// quote returns s as a quoted string for GraphViz, escaping only quotes,
+// backslashes and line breaks. Unlike the Go quoting of the dot package, any
+// other character is kept as is, e.g. the zero width joiner of an emoji such
+// as 🧑‍💻, which GraphViz would show as an escape sequence such as \u200d.
+func quote(s string) dot.Literal {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	return dot.Literal(`"` + r.Replace(s) + `"`)
+}
+
+// unique returns true when s is not present in values
+func unique(s string, vals []string) bool {
+	for _, v := range vals {
+		if s == v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,1970 @@
+package vimwiki
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/dot"
+)
+
+func TestMappingCollapse(t *testing.T) {
+	cases := []match{
+		match{
+			text:   "[[diary/link]]",
+			links:  []string{"diary"},
+			dir:    []string{"."},
+			ignore: "",
+		},
+		match{
+			text:   "[[../link]]",
+			links:  []string{"link.wiki"},
+			dir:    []string{"diary"},
+			ignore: "",
+		},
+		match{
+			text:   "[[link]]",
+			links:  []string{"diary"},
+			dir:    []string{"diary"},
+			ignore: "",
+		},
+	}
+
+	wiki := Wiki{}
+	if err := wiki.CompileExpressions(); err != nil {
+		t.Error(err)
+	}
+	wiki.remap = make(map[string]string)
+	wiki.remap["diary"] = "diary"
+
+	for _, c := range cases {
+		for i, m := range wiki.Links(c.text) {
+
+			_, link := wiki.Remap(c.dir[i], ".", m)
+
+			if link != c.links[i] {
+				t.Errorf("Expected link: %v:, got: %v", c.links[i], link)
+			}
+		}
+	}
+}
+
+func TestMappingNoCollapse(t *testing.T) {
+	cases := []match{
+		match{
+			text:    "[[diary/link]]",
+			matches: []string{"[[diary/link]]"},
+			links:   []string{"diary/link.wiki"},
+			dir:     []string{"."},
+			ignore:  "",
+		},
+		match{
+			text:    "[[../link]]",
+			matches: []string{"[[../link]]"},
+			links:   []string{"link.wiki"},
+			dir:     []string{"diary.wiki"},
+			ignore:  "",
+		},
+		match{
+			text:   "[[link]]",
+			links:  []string{"diary/link.wiki"},
+			dir:    []string{"diary"},
+			ignore: "",
+		},
+	}
+
+	wiki := Wiki{}
+	if err := wiki.CompileExpressions(); err != nil {
+		t.Error(err)
+	}
+
+	for _, c := range cases {
+		for i, m := range wiki.Links(c.text) {
+
+			_, link := wiki.Remap(c.dir[i], ".", m)
+
+			if link != c.links[i] {
+				t.Errorf("Expected link: %v:, got: %v", c.links[i], link)
+			}
+		}
+	}
+}
+
+func TestNodeConnectionLevel(t *testing.T) {
+	os.Chdir(".")
+	dir, _ := os.Executable()
+	t.Log(dir)
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+
+	if err != nil {
+		t.Errorf("Expected no error in constructor")
+	}
+
+	// build 0 < i < 10 entries with each i connections, where the current
+	// nodes are not considered as connections
+	wiki.graph = make(map[string][]string)
+	for i := 0; i < 10; i++ {
+		k := fmt.Sprintf("%d", i)
+		wiki.graph[k] = make([]string, 0, 0)
+		for j := 0; j < i; j++ {
+			wiki.graph[k] = append(wiki.graph[k], fmt.Sprintf("%d00", j))
+		}
+	}
+
+	// level zero: 9 entries + 10 connections: 19 nodes in total
+	// each increment of the level should draw one node less
+	for l := 0; l < 10; l++ {
+		g := wiki.Dot(l, dot.Directed)
+		nconn := len(g.FindNodes())
+		exp := 19 - l
+		if nconn != exp {
+			t.Errorf("For level %v: exp: %v, got %v", l, exp, nconn)
+		}
+	}
+
+	// higher than maximum connectivity should result in zero nodes
+	nconn := len(wiki.Dot(10, dot.Directed).FindNodes())
+	if nconn > 0 {
+		t.Errorf("Expected 0 connections, got %v", nconn)
+	}
+}
+
+func TestIgnorePaths(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "t*")
+	if err != nil {
+		t.Errorf("Expected no error in constructor")
+	}
+	if !wiki.IgnorePath("test") {
+		t.Errorf("Path should be discarged given the regex")
+	}
+
+	wiki, err = NewWiki("example", make(map[string]string), false, "^archive/", "draft", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, exp := range map[string]bool{
+		"archive/note.wiki": true,
+		"a/draft.wiki":      true,
+		"a/archive/x.wiki":  false,
+		"index.wiki":        false,
+	} {
+		if got := wiki.IgnorePath(path); got != exp {
+			t.Errorf("Expected IgnorePath(%v) to be %v, got %v", path, exp, got)
+		}
+	}
+
+	if _, err := NewWiki("example", make(map[string]string), false, "a", "("); err == nil {
+		t.Errorf("Expected an error for an invalid regex")
+	}
+}
+
+// writeWiki writes files, mapping paths to their content, into a temporary
+// directory and returns its path. The directory is removed by calling clean.
+func writeWiki(t *testing.T, files map[string]string) (root string, clean func()) {
+	root, err := ioutil.TempDir("", "wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, text := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, func() { os.RemoveAll(root) }
+}
+
+func TestTitles(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki": "= Index =\n[[note]]\n= Other heading =",
+		"note.md":    "no heading",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.Titles = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, label := range []string{`"Index"`, `"note.md"`, `"note.wiki"`} {
+		if !strings.Contains(out, label) {
+			t.Errorf("Expected label %v in output:\n%v", label, out)
+		}
+	}
+	if strings.Contains(out, `"index.wiki"`) {
+		t.Errorf("Expected index.wiki to be labelled by its title:\n%v", out)
+	}
+}
+
+func TestEdgeLabels(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki": "[[note|first]]\n[[note|second]]\n[[other]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.EdgeLabels = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if label := wiki.edges[[2]string{"index.wiki", "note.wiki"}].Label; label != "first" {
+		t.Errorf("Expected first description to be retained, got %v", label)
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	if !strings.Contains(out, `[label="first"]`) {
+		t.Errorf("Expected edge label in output:\n%v", out)
+	}
+	if strings.Contains(out, "second") || strings.Contains(out, `label=""`) {
+		t.Errorf("Expected only the first description in output:\n%v", out)
+	}
+}
+
+func TestUndirected(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"a.wiki": {"b.wiki", "c.wiki"},
+		"b.wiki": {"a.wiki"},
+	}
+
+	wiki.Undirected = true
+	out := wiki.Dot(0, dot.Directed).String()
+	if !strings.HasPrefix(out, "graph") {
+		t.Errorf("Expected an undirected graph:\n%v", out)
+	}
+	if n := strings.Count(out, "--"); n != 2 {
+		t.Errorf("Expected 2 undirected edges, got %v:\n%v", n, out)
+	}
+
+	wiki.Undirected = false
+	if n := strings.Count(wiki.Dot(0, dot.Directed).String(), "->"); n != 3 {
+		t.Errorf("Expected 3 directed edges, got %v", n)
+	}
+}
+
+func TestShapes(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"a.wiki": {"b.md", "c.png"},
+	}
+	wiki.Shapes = true
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{
+		`[label="a.wiki",shape="box"]`,
+		`[label="b.md",shape="ellipse"]`,
+		`[label="c.png",shape="hexagon"]`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+}
+
+func TestAssets(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"sub/note.md": "![figure](image.png)\n[paper](paper.pdf)\n[note](other.md)",
+	})
+	defer clean()
+
+	for _, assets := range []bool{false, true} {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.Assets = assets
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := []string{"sub/other.md"}
+		if assets {
+			exp = []string{"sub/image.png", "sub/paper.pdf", "sub/other.md"}
+		}
+		links := wiki.graph["sub/note.md"]
+		if len(links) != len(exp) {
+			t.Fatalf("Expected links %v, got %v", exp, links)
+		}
+		for i, l := range links {
+			if l != exp[i] {
+				t.Errorf("Expected link %v, got %v", exp[i], l)
+			}
+		}
+
+		out := wiki.Dot(0, dot.Directed).String()
+		if n := strings.Count(out, `style="dotted"`); assets && n != 2 {
+			t.Errorf("Expected 2 asset edges, got %v:\n%v", n, out)
+		}
+	}
+}
+
+func TestMappingPathBoundaries(t *testing.T) {
+	wiki, err := NewWiki("example", map[string]string{"diary": "diary.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		dir, link, exp string
+	}{
+		{".", "diary/x.wiki", "diary.wiki"},
+		{".", "diary/sub/x.wiki", "diary.wiki"},
+		{".", "diary-old/x.wiki", "diary-old/x.wiki"},
+		{".", "my-diary-notes/x.wiki", "my-diary-notes/x.wiki"},
+		{"notes", "diary.wiki", "notes/diary.wiki"},
+	}
+	for _, c := range cases {
+		if _, link := wiki.Remap(c.dir, "key", c.link); link != c.exp {
+			t.Errorf("Expected link: %v, got: %v", c.exp, link)
+		}
+	}
+
+	// only files inside the diary are collapsed as well
+	if key, _ := wiki.Remap("diary", "diary/x.wiki", "x.wiki"); key != "diary.wiki" {
+		t.Errorf("Expected key diary.wiki, got %v", key)
+	}
+	if key, _ := wiki.Remap("diary-old", "diary-old/x.wiki", "x.wiki"); key != "diary-old/x.wiki" {
+		t.Errorf("Expected key diary-old/x.wiki, got %v", key)
+	}
+}
+
+func TestNoSelfLoops(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"self.wiki":    "[[self]]\n[[other]]",
+		"index.wiki":   "[[diary/a]]",
+		"diary/a.wiki": "[[b]]\n[[../index]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, map[string]string{"diary": "diary.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.NoSelfLoops = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string][]string{
+		"self.wiki":  {"other.wiki"},
+		"index.wiki": {"diary.wiki"},
+		"diary.wiki": {"index.wiki"},
+	}
+	for k, links := range exp {
+		if fmt.Sprint(wiki.graph[k]) != fmt.Sprint(links) {
+			t.Errorf("Expected links %v for %v, got %v", links, k, wiki.graph[k])
+		}
+	}
+}
+
+func TestTooltips(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"a.wiki": {"b.wiki", "c.wiki"},
+		"b.wiki": {"a.wiki"},
+	}
+	wiki.Tooltips = true
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{
+		`[label="a.wiki",tooltip="2 outgoing, 1 incoming links"]`,
+		`[label="c.wiki",tooltip="0 outgoing, 1 incoming links"]`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki": "", "b.wiki": "", "c.wiki": "", "d.wiki": "", "e.wiki": "",
+		// binary files are not parsed and do not report progress
+		"a.png": "", "b.png": "",
+	})
+	defer clean()
+
+	defer func(interval int) { progressInterval = interval }(progressInterval)
+	progressInterval = 2
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	wiki.Progress = &buf
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "processed 2 files\nprocessed 4 files\nprocessed 5 files in total\n"
+	if buf.String() != exp {
+		t.Errorf("Expected progress %q, got %q", exp, buf.String())
+	}
+}
+
+func TestContinueOnError(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{"a.wiki": "[[b]]"})
+	defer clean()
+
+	// a dangling symlink cannot be opened
+	broken := filepath.Join(root, "broken.wiki")
+	if err := os.Symlink(filepath.Join(root, "missing.wiki"), broken); err != nil {
+		t.Fatal(err)
+	}
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err == nil {
+		t.Errorf("Expected walk to abort on unreadable file")
+	}
+
+	wiki, err = NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.ContinueOnError = true
+	err = wiki.Walk(nil)
+	errs, ok := err.(WalkErrors)
+	if !ok || len(errs) != 1 || errs[broken] == nil {
+		t.Fatalf("Expected error for %v, got %v", broken, err)
+	}
+	if links := wiki.graph["a.wiki"]; len(links) != 1 {
+		t.Errorf("Expected readable file to be added, got %v", wiki.graph)
+	}
+}
+
+func TestBinaryFiles(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"note.wiki":  "[[data]]",
+		"data":       "\x00\x01[[hidden]]",
+		"image.png":  "[[hidden]]",
+		"empty.wiki": "",
+	})
+	defer clean()
+
+	for _, assets := range []bool{false, true} {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.Assets = assets
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, path := range []string{"data", "image.png"} {
+			links, ok := wiki.graph[path]
+			if ok != assets || len(links) != 0 {
+				t.Errorf("Expected binary %v as asset %v, got %v", path, assets, wiki.graph)
+			}
+			if wiki.assets[path] != assets {
+				t.Errorf("Expected %v to be marked as asset: %v", path, assets)
+			}
+		}
+		if _, ok := wiki.graph["empty.wiki"]; !ok {
+			t.Errorf("Expected empty file not to be treated as binary")
+		}
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("VIMWIKIGRAPH_TEST", "/tmp/wiki")
+	defer os.Unsetenv("VIMWIKIGRAPH_TEST")
+
+	cases := map[string]string{
+		"~":                      home,
+		"~/sub":                  filepath.Join(home, "sub"),
+		"$VIMWIKIGRAPH_TEST/sub": "/tmp/wiki/sub",
+		"~user/sub":              "~user/sub",
+		"example":                "example",
+	}
+	for path, exp := range cases {
+		got, err := ExpandPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != exp {
+			t.Errorf("Expected %v to expand to %v, got %v", path, exp, got)
+		}
+	}
+}
+
+func TestSizeByDegree(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"hub.wiki": {"a.wiki", "b.wiki", "c.wiki", "d.wiki"},
+		"a.wiki":   {"b.wiki"},
+	}
+	wiki.SizeByDegree = true
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{
+		`[fontsize="30.0",label="hub.wiki"]`,
+		`[fontsize="20.0",label="a.wiki"]`,
+		`[fontsize="15.0",label="c.wiki"]`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"index.wiki": {"diary/today.wiki", "other.wiki"},
+	}
+
+	if err := wiki.Highlight("index.wiki", "diary/today.wiki"); err != nil {
+		t.Fatal(err)
+	}
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, label := range []string{"index.wiki", "diary/today.wiki"} {
+		exp := fmt.Sprintf(`[color="red",fontcolor="red",label=%q,penwidth="3"]`, label)
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+	if n := strings.Count(out, "red"); n != 4 {
+		t.Errorf("Expected only two highlighted nodes:\n%v", out)
+	}
+
+	if err := wiki.Highlight("missing.wiki"); err == nil {
+		t.Errorf("Expected error when highlighting a missing node")
+	}
+}
+
+func TestMappingBackslashes(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("backslashes are part of file names on", runtime.GOOS)
+	}
+	wiki, err := NewWiki("example", map[string]string{"diary": "diary.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		dir, link, exp string
+	}{
+		{`notes\sub`, `..\index.wiki`, "notes/index.wiki"},
+		{".", `diary\today.wiki`, "diary.wiki"},
+		{`notes`, `a\b.wiki`, "notes/a/b.wiki"},
+	}
+	for _, c := range cases {
+		if _, link := wiki.Remap(c.dir, "key", c.link); link != c.exp {
+			t.Errorf("Expected link: %v, got: %v", c.exp, link)
+		}
+	}
+	if key, _ := wiki.Remap(`diary\sub`, `diary\sub\x.wiki`, "x.wiki"); key != "diary.wiki" {
+		t.Errorf("Expected key diary.wiki, got %v", key)
+	}
+}
+
+func TestBackslashInName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("backslashes are separators on windows")
+	}
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a backslash is a valid character in a file name
+	if _, link := wiki.Remap("notes", "key", `a\b.wiki`); link != `notes/a\b.wiki` {
+		t.Errorf("Expected link: %v, got: %v", `notes/a\b.wiki`, link)
+	}
+}
+
+func TestMergeReciprocal(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki": "[[b]]\n[[c]]",
+		"b.wiki": "[[a]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+	wiki.MergeReciprocal = true
+
+	out := wiki.Dot(0, dot.Directed).String()
+	if n := strings.Count(out, "->"); n != 2 {
+		t.Errorf("Expected 2 edges, got %v:\n%v", n, out)
+	}
+	if n := strings.Count(out, `dir="both"`); n != 1 {
+		t.Errorf("Expected 1 bidirectional edge, got %v:\n%v", n, out)
+	}
+}
+
+func TestMappingRootRelative(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		dir, text, exp string
+	}{
+		{"notes", "[[projects/2023/plan]]", "notes/projects/2023/plan.wiki"},
+		{"notes", "[[/projects/2023/plan]]", "projects/2023/plan.wiki"},
+		{"notes/sub", "[[/projects/../index]]", "index.wiki"},
+		{".", "[[/projects/2023/plan]]", "projects/2023/plan.wiki"},
+	}
+	for _, c := range cases {
+		for _, m := range wiki.Links(c.text) {
+			if _, link := wiki.Remap(c.dir, "key", m); link != c.exp {
+				t.Errorf("Expected link: %v, got: %v", c.exp, link)
+			}
+		}
+	}
+}
+
+func TestMappingAbsolute(t *testing.T) {
+	wiki, err := NewWiki("/home/user/wiki", map[string]string{"diary": "diary.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		dir, text, exp string
+	}{
+		{".", "[[/index]]", "index.wiki"},
+		{"notes", "[[/index]]", "index.wiki"},
+		{"notes/sub", "[[/index|home]]", "index.wiki"},
+		{"notes", "[[/diary/today]]", "diary.wiki"},
+		{"notes", "[[//home/user/wiki/notes/a]]", "notes/a.wiki"},
+		{"notes", "[[//home/user/other/b]]", "/home/user/other/b.wiki"},
+		{"notes", "[[//home/user/wiki-old/c]]", "/home/user/wiki-old/c.wiki"},
+	}
+	for _, c := range cases {
+		for _, m := range wiki.Links(c.text) {
+			if _, link := wiki.Remap(c.dir, "key", m); link != c.exp {
+				t.Errorf("Expected link: %v, got: %v", c.exp, link)
+			}
+		}
+	}
+}
+
+func TestRecordLabels(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"notes/a&b.wiki": {"<c>.wiki", "d.wiki"},
+	}
+	wiki.RecordLabels = true
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{
+		`<tr><td><b>notes/a&amp;b.wiki</b></td></tr><tr><td>notes</td></tr><tr><td>2 outgoing links</td></tr>`,
+		`<tr><td><b>&lt;c&gt;.wiki</b></td></tr><tr><td>.</td></tr><tr><td>0 outgoing links</td></tr>`,
+		`shape="plaintext"`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+}
+
+func TestMaxEdges(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"hub.wiki": "[[a]] [[b]] [[c]] [[d]]\n[[c]] [[a]]\n[[a]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+	if w := wiki.edges[[2]string{"hub.wiki", "a.wiki"}].Weight; w != 3 {
+		t.Errorf("Expected weight 3, got %v", w)
+	}
+	if links := fmt.Sprint(wiki.strongest("hub.wiki", 2)); links != "[a.wiki c.wiki]" {
+		t.Errorf("Expected strongest links [a.wiki c.wiki], got %v", links)
+	}
+
+	wiki.MaxEdges = 2
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{`"a.wiki"`, `"c.wiki"`, `label="…+2 more"`} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+	for _, label := range []string{`"b.wiki"`, `"d.wiki"`} {
+		if strings.Contains(out, label) {
+			t.Errorf("Expected %v to be collapsed:\n%v", label, out)
+		}
+	}
+	if n := strings.Count(out, "->"); n != 3 {
+		t.Errorf("Expected 3 edges, got %v:\n%v", n, out)
+	}
+}
+
+func TestExternals(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"sub/note.md": "[site](https://a.com)\nhttps://b.com/page\n[[other]]",
+	})
+	defer clean()
+
+	for _, externals := range []bool{false, true} {
+		wiki, err := NewWiki(root, make(map[string]string), true, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.Externals = externals
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := "[sub/other.wiki]"
+		if externals {
+			exp = "[https://a.com https://b.com/page sub/other.wiki]"
+		}
+		if links := fmt.Sprint(wiki.graph["sub/note.md"]); links != exp {
+			t.Errorf("Expected links %v, got %v", exp, links)
+		}
+
+		out := wiki.Dot(0, dot.Directed).String()
+		if externals && !strings.Contains(out, `label="external"`) {
+			t.Errorf("Expected external cluster in output:\n%v", out)
+		}
+	}
+}
+
+func TestFaintBelowLevel(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"hub.wiki":  {"a.wiki", "b.wiki"},
+		"leaf.wiki": {"c.wiki"},
+	}
+
+	if out := wiki.Dot(2, dot.Directed).String(); strings.Contains(out, `"leaf.wiki"`) {
+		t.Errorf("Expected leaf.wiki to be left out:\n%v", out)
+	}
+
+	wiki.FaintBelowLevel = true
+	g := wiki.Dot(2, dot.Directed)
+	if n := len(g.FindNodes()); n != 5 {
+		t.Errorf("Expected 5 nodes, got %v", n)
+	}
+	out := g.String()
+	for _, exp := range []string{
+		`[color="grey",fontcolor="grey",label="leaf.wiki"]`,
+		`color="grey",style="dashed"`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+	if n := strings.Count(out, `style="dashed"`); n != 1 {
+		t.Errorf("Expected 1 dashed edge, got %v:\n%v", n, out)
+	}
+}
+
+func TestDegreeMode(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"hub.wiki": {},
+		"a.wiki":   {"hub.wiki"},
+		"b.wiki":   {"hub.wiki"},
+		"c.wiki":   {"hub.wiki", "a.wiki"},
+	}
+
+	// at level 2, only hub.wiki has enough incoming links and c.wiki enough
+	// outgoing links, while a.wiki has one of each and b.wiki is left out
+	for mode, exp := range map[string]int{"": 3, "total": 3, "out": 3, "in": 1} {
+		wiki.DegreeMode = mode
+		if n := len(wiki.Dot(2, dot.Directed).FindNodes()); n != exp {
+			t.Errorf("Expected %v nodes for mode %q, got %v", exp, mode, n)
+		}
+	}
+}
+
+func TestClusterComponents(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"a/x.wiki": {"b/y.wiki"},
+		"c.wiki":   {"d.wiki"},
+		"e.wiki":   {},
+	}
+	wiki.ClusterComponents = true
+
+	g := wiki.Dot(0, dot.Directed)
+	for i, exp := range [][]string{{"a/x.wiki", "b/y.wiki"}, {"c.wiki", "d.wiki"}, {"e.wiki"}} {
+		title := fmt.Sprintf("component %d", i+1)
+		sub := g.Subgraph(title, dot.ClusterOption{})
+		out := sub.String()
+		if n := len(sub.FindNodes()); n != len(exp) {
+			t.Errorf("Expected %v nodes in %v, got %v:\n%v", len(exp), title, n, out)
+		}
+		for _, n := range exp {
+			if !strings.Contains(out, fmt.Sprintf("label=%q", n)) {
+				t.Errorf("Expected %v in %v:\n%v", n, title, out)
+			}
+		}
+	}
+
+	// directories are not clustered
+	out := g.String()
+	if strings.Contains(out, `label="a/"`) {
+		t.Errorf("Expected no directory clusters in output:\n%v", out)
+	}
+}
+
+func TestAddFiles(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki":     "[[b]]",
+		"sub/b.wiki": "[[c]]",
+		"c.wiki":     "[[a]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(filepath.Dir(root), "outside.wiki")
+	if err := wiki.AddFiles([]string{outside}); err == nil {
+		t.Errorf("Expected error for file outside of the wiki")
+	}
+
+	wiki.ContinueOnError = true
+	paths := []string{filepath.Join(root, "a.wiki"), filepath.Join(root, "sub", "b.wiki"), outside}
+	err = wiki.AddFiles(paths)
+	if errs, ok := err.(WalkErrors); !ok || len(errs) != 1 || errs[outside] == nil {
+		t.Errorf("Expected error for %v, got %v", outside, err)
+	}
+
+	exp := map[string]string{"a.wiki": "[b.wiki]", "sub/b.wiki": "[sub/c.wiki]"}
+	if len(wiki.graph) != len(exp) {
+		t.Errorf("Expected only the given files, got %v", wiki.graph)
+	}
+	for k, links := range exp {
+		if got := fmt.Sprint(wiki.graph[k]); got != links {
+			t.Errorf("Expected links %v for %v, got %v", links, k, got)
+		}
+	}
+}
+
+func TestSingleFile(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"notes/a.wiki": "[[b]] [[sub/c]] [text](d.md)",
+		"notes/b.wiki": "[[a]] [[e]]",
+	})
+	defer clean()
+
+	// a single note is added relative to its own directory
+	file := filepath.Join(root, "notes", "a.wiki")
+	wiki, err := NewWiki(filepath.Dir(file), make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.AddFiles([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "[[a.wiki b.wiki] [a.wiki d.md] [a.wiki sub/c.wiki]]"
+	if got := fmt.Sprint(wiki.Edges()); got != exp {
+		t.Errorf("Expected only the links of the file, got %v", got)
+	}
+}
+
+func TestSingleFileHome(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"notes/a.wiki": "[[b]]",
+	})
+	defer clean()
+
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", root)
+	defer os.Setenv("HOME", home)
+
+	// the path is expanded before deciding whether it is a single file
+	file, err := ExpandPath("~/notes/a.wiki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.IsDir() {
+		t.Fatalf("Expected %v to be a file", file)
+	}
+
+	wiki, err := NewWiki(filepath.Dir(file), make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.AddFiles([]string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(wiki.Edges()); got != "[[a.wiki b.wiki]]" {
+		t.Errorf("Expected only the links of the file, got %v", got)
+	}
+}
+
+func TestMergeExtensions(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"note.wiki": "[[other]]",
+		"other.md":  "",
+		"index.md":  "[[note]]\n[n](note.md)\n[o](other.md)",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.MergeExtensions = true
+	wiki.Shapes = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]string{"index": "[note other]", "note": "[other]", "other": "[]"}
+	if len(wiki.graph) != len(exp) {
+		t.Errorf("Expected %v nodes, got %v", len(exp), wiki.graph)
+	}
+	for k, links := range exp {
+		if got := fmt.Sprint(wiki.graph[k]); got != links {
+			t.Errorf("Expected links %v for %v, got %v", links, k, got)
+		}
+	}
+
+	// nodes are labelled by the name of their file
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, label := range []string{
+		`label="index.md",shape="ellipse"`,
+		`label="note.wiki",shape="box"`,
+		`label="other.md",shape="ellipse"`,
+	} {
+		if !strings.Contains(out, label) {
+			t.Errorf("Expected %v in output:\n%v", label, out)
+		}
+	}
+}
+
+func TestInterWiki(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"sub/index.wiki": "[[wiki0:index]] [[wiki1:index]] [[index]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// pages in other wikis are not relative to the current directory
+	exp := "[wiki0:index wiki1:index sub/index.wiki]"
+	if links := fmt.Sprint(wiki.graph["sub/index.wiki"]); links != exp {
+		t.Errorf("Expected links %v, got %v", exp, links)
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, s := range []string{`label="wiki0"`, `label="wiki1"`, `label="wiki0:index",style="dashed"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %v in output:\n%v", s, out)
+		}
+	}
+}
+
+func TestEdgeLines(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki": "= Title =\n[[b]]\ntext [[c]] [[b]]\n\nsee [ref][]\n\n[ref]: c.wiki",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.Tooltips = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for target, exp := range map[string]string{"b.wiki": "[2 3]", "c.wiki": "[3 5]"} {
+		edge, ok := wiki.Edge("a.wiki", target)
+		if !ok {
+			t.Fatalf("Expected edge to %v", target)
+		}
+		if lines := fmt.Sprint(edge.Lines); lines != exp {
+			t.Errorf("Expected lines %v for %v, got %v", exp, target, lines)
+		}
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	if !strings.Contains(out, `tooltip="lines 2, 3"`) {
+		t.Errorf("Expected edge tooltip in output:\n%v", out)
+	}
+}
+
+func TestURLs(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"sub/my note.wiki": {"index.wiki", "https://a.com/x"},
+	}
+	wiki.externals = map[string]bool{"https://a.com/x": true}
+	wiki.URLs = true
+
+	abs, err := filepath.Abs("example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{
+		fmt.Sprintf(`URL="file://%s/sub/my%%20note.wiki"`, filepath.ToSlash(abs)),
+		fmt.Sprintf(`URL="file://%s/index.wiki"`, filepath.ToSlash(abs)),
+		`URL="https://a.com/x"`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+
+	wiki.URLBase = "https://example.com/wiki/"
+	out = wiki.Dot(0, dot.Directed).String()
+	if exp := `URL="https://example.com/wiki/sub/my%20note.wiki"`; !strings.Contains(out, exp) {
+		t.Errorf("Expected %v in output:\n%v", exp, out)
+	}
+}
+
+func TestMappingDiaryDir(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":               "[[journal/2023-01-01]] [[diary/note]]",
+		"journal/2023-01-01.wiki":  "[[2023-01-02]] [[../index]]",
+		"journal/2023-01-02.wiki":  "",
+		"notes/journal/other.wiki": "[[/index]]",
+		"diary/note.wiki":          "",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, map[string]string{"journal": "journal.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.NoSelfLoops = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]string{
+		"index.wiki":               "[journal.wiki diary/note.wiki]",
+		"journal.wiki":             "[index.wiki]",
+		"notes/journal/other.wiki": "[index.wiki]",
+	}
+	for k, links := range exp {
+		if got := fmt.Sprint(wiki.graph[k]); got != links {
+			t.Errorf("Expected links %v for %v, got %v", links, k, got)
+		}
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":      "[[diary/2023-01-01]]",
+		"skip/note.wiki":  "",
+		"diary/note.wiki": "",
+	})
+	defer clean()
+
+	for level, exp := range map[int][]string{
+		LogWarnings: {},
+		LogDirs: {
+			"entering: " + root, "entering: " + filepath.Join(root, "diary"),
+			"skipping: " + filepath.Join(root, "skip"),
+		},
+		LogLinks: {
+			"skipping: " + filepath.Join(root, "skip"),
+			"index.wiki:1: link to diary/2023-01-01.wiki",
+			"index.wiki:1: renamed to index.wiki -> diary.wiki",
+		},
+	} {
+		var buf bytes.Buffer
+		wiki, err := NewWiki(root, map[string]string{"diary": "diary.wiki"}, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.Verbosity = level
+		wiki.Log = &buf
+		if err := wiki.Walk([]string{"skip"}); err != nil {
+			t.Fatal(err)
+		}
+
+		out := buf.String()
+		if len(exp) == 0 && out != "" {
+			t.Errorf("Expected no messages at level %v, got:\n%v", level, out)
+		}
+		for _, msg := range exp {
+			if !strings.Contains(out, msg+"\n") {
+				t.Errorf("Expected %q at level %v, got:\n%v", msg, level, out)
+			}
+		}
+		if level < LogLinks && strings.Contains(out, "link to") {
+			t.Errorf("Expected no links at level %v, got:\n%v", level, out)
+		}
+	}
+}
+
+func TestWalkCurrentDirectory(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":  "[[a/note]]",
+		"a/note.wiki": "[[../index]]",
+	})
+	defer clean()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, dir := range []string{".", "./"} {
+		wiki, err := NewWiki(dir, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatalf("Expected no error when walking %q, got %v", dir, err)
+		}
+		exp := "[[a/note.wiki index.wiki] [index.wiki a/note.wiki]]"
+		if got := fmt.Sprint(wiki.Edges()); got != exp {
+			t.Errorf("Expected edges %v when walking %q, got %v", exp, dir, got)
+		}
+	}
+}
+
+func TestFollowSymlinks(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":      "[[shared/ref]]",
+		"notes/note.wiki": "[[../index]]",
+	})
+	defer clean()
+	shared, cleanShared := writeWiki(t, map[string]string{
+		"ref.wiki": "[[other]]",
+	})
+	defer cleanShared()
+
+	// links outside the tree, into the tree and back out of the shared tree
+	for link, target := range map[string]string{
+		filepath.Join(root, "shared"):  shared,
+		filepath.Join(root, "loop"):    root,
+		filepath.Join(root, "alias"):   filepath.Join(root, "notes"),
+		filepath.Join(shared, "again"): shared,
+	} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, follow := range []bool{false, true} {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.FollowSymlinks = follow
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		exp := "[index.wiki notes/note.wiki]"
+		if follow {
+			exp = "[index.wiki notes/note.wiki shared/ref.wiki]"
+		}
+		files := make([]string, 0, len(wiki.graph))
+		for k := range wiki.graph {
+			files = append(files, k)
+		}
+		sort.Strings(files)
+		if got := fmt.Sprint(files); got != exp {
+			t.Errorf("Expected files %v when following symlinks is %v, got %v", exp, follow, got)
+		}
+	}
+}
+
+func TestCleanKeys(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"foo.wiki":             "[[./bar]] [[sub/../bar]] [[./foo]]",
+		"bar.wiki":             "[[sub//baz]] [[./sub/./baz]]",
+		"sub/baz.wiki":         "[[../foo]]",
+		"journal/2023-01.wiki": "= Entry =\n[[../foo]]",
+		"journal/2023-02.wiki": "",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, map[string]string{"./journal/": "./journal.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.Titles = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// collapsed files are not present by their own name
+	exp := map[string]string{
+		"foo.wiki":     "[bar.wiki foo.wiki]",
+		"bar.wiki":     "[sub/baz.wiki]",
+		"sub/baz.wiki": "[foo.wiki]",
+		"journal.wiki": "[foo.wiki]",
+	}
+	if len(wiki.graph) != len(exp) {
+		t.Errorf("Expected %v nodes, got %v", len(exp), wiki.graph)
+	}
+	for k, links := range exp {
+		if got := fmt.Sprint(wiki.graph[k]); got != links {
+			t.Errorf("Expected links %v for %v, got %v", links, k, got)
+		}
+	}
+	if _, ok := wiki.titles["journal.wiki"]; ok {
+		t.Errorf("Expected no title for collapsed files, got %v", wiki.titles)
+	}
+
+	if n := len(wiki.Dot(0, dot.Directed).FindNodes()); n != len(exp) {
+		t.Errorf("Expected %v nodes in output, got %v", len(exp), n)
+	}
+}
+
+func TestMaxNodes(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki": "[[b]] [[c]]",
+		"b.wiki": "[[a]]",
+	})
+	defer clean()
+
+	for max, fail := range map[int]bool{0: false, 3: false, 2: true} {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.MaxNodes = max
+		wiki.ContinueOnError = true
+
+		err = wiki.Walk(nil)
+		if _, ok := err.(*MaxNodesError); ok != fail {
+			t.Errorf("Expected error %v for at most %v nodes, got %v", fail, max, err)
+		}
+	}
+}
+
+func TestCaption(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"a.wiki": {"b.wiki", "c.wiki"},
+		"b.wiki": {"a.wiki"},
+	}
+
+	if out := wiki.Dot(0, dot.Directed).String(); strings.Contains(out, "labelloc") {
+		t.Errorf("Expected no caption by default:\n%v", out)
+	}
+
+	wiki.Caption = "My Notes"
+	out := wiki.Dot(0, dot.Directed).String()
+	if !strings.Contains(out, `label="My Notes"`) || !strings.Contains(out, `labelloc="t"`) {
+		t.Errorf("Expected caption in output:\n%v", out)
+	}
+
+	wiki.Metadata = true
+	out = wiki.Dot(0, dot.Directed).String()
+	if !strings.Contains(out, `label="My Notes\ngenerated `) || !strings.Contains(out, `, 3 nodes, 3 edges"`) {
+		t.Errorf("Expected caption with metadata in output:\n%v", out)
+	}
+}
+
+func TestTaskEdges(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki": "- [ ] do [[b]]\n- [X] done [[c]]\n- [[d]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.Tasks = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for target, exp := range map[string]bool{"b.wiki": true, "c.wiki": true, "d.wiki": false} {
+		edge, ok := wiki.Edge("a.wiki", target)
+		if !ok {
+			t.Fatalf("Expected edge to %v", target)
+		}
+		if edge.Task != exp {
+			t.Errorf("Expected task %v for %v, got %v", exp, target, edge.Task)
+		}
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	if n := strings.Count(out, `style="dashed"`); n != 2 {
+		t.Errorf("Expected two dashed edges, got %v in output:\n%v", n, out)
+	}
+}
+
+func TestEmbedEdges(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki": "![[b#section]]\n[[c]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for target, exp := range map[string]bool{"b.wiki": true, "c.wiki": false} {
+		edge, ok := wiki.Edge("a.wiki", target)
+		if !ok {
+			t.Fatalf("Expected edge to %v", target)
+		}
+		if edge.Embed != exp {
+			t.Errorf("Expected embed %v for %v, got %v", exp, target, edge.Embed)
+		}
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	if n := strings.Count(out, `style="bold"`); n != 1 {
+		t.Errorf("Expected one bold edge, got %v in output:\n%v", n, out)
+	}
+}
+
+func TestColorByAge(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"old.wiki": "[[index]]",
+		"mid.wiki": "[[index]]",
+		"new.wiki": "[[index]]",
+	})
+	defer clean()
+
+	now := time.Now()
+	for i, name := range []string{"old.wiki", "mid.wiki", "new.wiki"} {
+		mtime := now.Add(time.Duration(i-2) * time.Hour)
+		if err := os.Chtimes(filepath.Join(root, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.ColorByAge = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{`color="0.000 1.000 0.800"`, `color="0.167 1.000 0.800"`, `color="0.333 1.000 0.800"`} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+
+	// identical modification times are all considered recent
+	for _, name := range []string{"old.wiki", "mid.wiki"} {
+		if err := os.Chtimes(filepath.Join(root, name), now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(root, "new.wiki")); err != nil {
+		t.Fatal(err)
+	}
+	wiki, err = NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.ColorByAge = true
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+	out = wiki.Dot(0, dot.Directed).String()
+	if n := strings.Count(out, `color="0.333 1.000 0.800"`); n != 2 {
+		t.Errorf("Expected two green edges, got %v in output:\n%v", n, out)
+	}
+}
+
+func TestDiaryBy(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"diary/2023-01-30.wiki": {"index.wiki"},
+		"diary/2023-01-31.wiki": {"index.wiki"},
+		"diary/2023-02-01.wiki": {"index.wiki"},
+		"diary/2024-02-01.wiki": {"index.wiki"},
+	}
+
+	cases := map[string]map[string][]string{
+		"month": {
+			"2023-01": {"diary/2023-01-30.wiki", "diary/2023-01-31.wiki"},
+			"2023-02": {"diary/2023-02-01.wiki"},
+			"2024-02": {"diary/2024-02-01.wiki"},
+		},
+		"week": {
+			"2023-W05": {"diary/2023-01-30.wiki", "diary/2023-01-31.wiki", "diary/2023-02-01.wiki"},
+			"2024-W05": {"diary/2024-02-01.wiki"},
+		},
+		"year": {
+			"2023": {"diary/2023-01-30.wiki", "diary/2023-01-31.wiki", "diary/2023-02-01.wiki"},
+			"2024": {"diary/2024-02-01.wiki"},
+		},
+	}
+	for by, clusters := range cases {
+		wiki.DiaryBy = by
+		g := wiki.Dot(0, dot.Directed)
+		for title, nodes := range clusters {
+			sub := g.Subgraph(title, dot.ClusterOption{}).String()
+			if n := strings.Count(sub, `label="diary/`); n != len(nodes) {
+				t.Errorf("Expected %v nodes in %v by %v, got %v", len(nodes), title, by, sub)
+			}
+			for _, n := range nodes {
+				if !strings.Contains(sub, fmt.Sprintf("label=%q", n)) {
+					t.Errorf("Expected %v in cluster %v by %v, got %v", n, title, by, sub)
+				}
+			}
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":       "",
+		"a/note.wiki":      "",
+		"a/b/note.wiki":    "",
+		"a/b/c/note.wiki":  "",
+		"other/note.wiki":  "",
+		"other/d/old.wiki": "",
+	})
+	defer clean()
+
+	cases := map[int]string{
+		-1: "[a/b/c/note.wiki a/b/note.wiki a/note.wiki index.wiki other/d/old.wiki other/note.wiki]",
+		0:  "[index.wiki]",
+		1:  "[a/note.wiki index.wiki other/note.wiki]",
+	}
+	for max, exp := range cases {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.MaxDepth = max
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(wiki.Nodes()); got != exp {
+			t.Errorf("Expected nodes %v for depth %v, got %v", exp, max, got)
+		}
+	}
+}
+
+func TestCRLFNodes(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki": "[[link]]\r\n[[/sub/note|Note]]\r\n",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "[index.wiki link.wiki sub/note.wiki]"
+	if nodes := fmt.Sprint(wiki.Nodes()); nodes != exp {
+		t.Errorf("Expected nodes %v, got %q", exp, wiki.Nodes())
+	}
+}
+
+func TestSameTargetOneEdge(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"sub/index.wiki": "[[foo]] [foo](foo.wiki) [x](./foo.wiki)\n" +
+			"[[/sub/foo]] [x](<foo.wiki> \"Foo\") [[ foo ]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if edges := fmt.Sprint(wiki.Edges()); edges != "[[sub/index.wiki sub/foo.wiki]]" {
+		t.Errorf("Expected a single edge, got %q", wiki.Edges())
+	}
+	if edge, _ := wiki.Edge("sub/index.wiki", "sub/foo.wiki"); edge.Weight != 6 {
+		t.Errorf("Expected the edge to count all 6 links, got %v", edge.Weight)
+	}
+}
+
+func TestLegend(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{"a.wiki": {"b.wiki"}}
+	wiki.Caption = "wiki"
+	wiki.Metadata = true
+	wiki.Legend = true
+	wiki.Shapes = true
+	wiki.Tasks = true
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{
+		`label="legend"`,
+		`label="vimwiki note",shape="box"`,
+		`label="markdown note",shape="ellipse"`,
+		`label="link in task"`,
+		`style="dashed"`,
+		"2 nodes, 1 edges",
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+	for _, unexp := range []string{"external website", "asset"} {
+		if strings.Contains(out, unexp) {
+			t.Errorf("Expected no %v in the legend:\n%v", unexp, out)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{"a.wiki": "[[b]]", "c.wiki": ""})
+	defer clean()
+
+	// dangling symlinks cannot be opened
+	var broken []string
+	for _, name := range []string{"broken.wiki", "sub/broken.md"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join(root, "missing.wiki"), path); err != nil {
+			t.Fatal(err)
+		}
+		broken = append(broken, path)
+	}
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.ContinueOnError = true
+	wiki.Log = ioutil.Discard
+	if err := wiki.Walk(nil); err == nil {
+		t.Errorf("Expected an error for the unreadable files")
+	}
+
+	errs := wiki.Errors()
+	if len(errs) != len(broken) {
+		t.Errorf("Expected %v errors, got %v", len(broken), errs)
+	}
+	for _, path := range broken {
+		if !os.IsNotExist(errs[path]) {
+			t.Errorf("Expected a missing file error for %v, got %v", path, errs[path])
+		}
+	}
+	if nodes := fmt.Sprint(wiki.Nodes()); nodes != "[a.wiki b.wiki c.wiki]" {
+		t.Errorf("Expected the readable files to be added, got %v", nodes)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	wiki, err := NewWiki("example", make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.graph = map[string][]string{
+		"index.wiki": {"note.wiki"},
+		"note.wiki":  {"index.wiki"},
+	}
+	wiki.titles = map[string]string{"index.wiki": "Index", "note.wiki": "Note"}
+	wiki.Titles = true
+	wiki.Aliases = map[string]string{"index.wiki": "Home"}
+
+	g := wiki.Dot(0, dot.Directed)
+	out := g.String()
+	for _, exp := range []string{`label="Home"`, `label="Note"`} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+	if strings.Contains(out, `label="Index"`) {
+		t.Errorf("Expected alias to replace the title:\n%v", out)
+	}
+
+	// the aliased node keeps its identity and links
+	home, note := g.Node("index.wiki"), g.Node("note.wiki")
+	if len(g.FindEdges(home, note)) != 1 || len(g.FindEdges(note, home)) != 1 {
+		t.Errorf("Expected the aliased node to keep its edges:\n%v", out)
+	}
+}
+
+func TestHidden(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":            "[[note]]",
+		".index.wiki.swp":       "[[swap]]",
+		".obsidian/config.wiki": "[[config]]",
+		"sub/.draft.wiki":       "[[draft]]",
+	})
+	defer clean()
+
+	cases := map[bool]string{
+		false: "[index.wiki note.wiki]",
+		true:  "[.index.wiki.swp .obsidian/config.wiki index.wiki note.wiki sub/.draft.wiki sub/draft.wiki swap.wiki]",
+	}
+	for hidden, exp := range cases {
+		wiki, err := NewWiki(root, make(map[string]string), false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wiki.IncludeHidden = hidden
+		if err := wiki.Walk(nil); err != nil {
+			t.Fatal(err)
+		}
+		if nodes := fmt.Sprint(wiki.Nodes()); nodes != exp {
+			t.Errorf("Expected nodes %v when including hidden files is %v, got %v", exp, hidden, nodes)
+		}
+	}
+}
+
+func TestWalkContext(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"a.wiki":     "[[b]]",
+		"c/d.wiki":   "[[e]]",
+		"c/f/g.wiki": "",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := wiki.WalkContext(ctx, nil); err != context.Canceled {
+		t.Errorf("Expected walk to be canceled, got %v", err)
+	}
+	if nodes := wiki.Nodes(); len(nodes) != 0 {
+		t.Errorf("Expected no files to be added, got %v", nodes)
+	}
+
+	// cancel once the first file is added
+	wiki, err = NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	wiki.Verbosity = LogLinks
+	wiki.Log = writerFunc(func(p []byte) (int, error) {
+		if bytes.Contains(p, []byte("link to")) {
+			cancel()
+		}
+		return len(p), nil
+	})
+	if err := wiki.WalkContext(ctx, nil); err != context.Canceled {
+		t.Errorf("Expected walk to be canceled, got %v", err)
+	}
+	if nodes := fmt.Sprint(wiki.Nodes()); nodes != "[a.wiki b.wiki]" {
+		t.Errorf("Expected only the first file to be added, got %v", nodes)
+	}
+}
+
+// writerFunc is an io.Writer calling the function itself.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestMeta(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":      "= Index =\nsee [[a]] and [[b]]\n\n== Notes ==\none two\n",
+		"a.wiki":          "# A note\n",
+		"diary/day.wiki":  "= Day =\nfour words in here",
+		"diary/next.wiki": "two words",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, map[string]string{"diary": "diary.wiki"}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]string{
+		"index.wiki": "8 words: Index, Notes",
+		"a.wiki":     "2 words: A note",
+		// collapsed nodes count all their files, but have no headings
+		"diary.wiki": "7 words",
+	}
+	for n, e := range exp {
+		if m, ok := wiki.meta[n]; !ok || m.String() != e {
+			t.Errorf("Expected %q for %v, got %v", e, n, m)
+		}
+	}
+
+	wiki.Meta = true
+	out := wiki.Dot(0, dot.Directed).String()
+	if !strings.Contains(out, `tooltip="8 words: Index, Notes"`) {
+		t.Errorf("Expected tooltip with the words and headings in output:\n%v", out)
+	}
+	wiki.Tooltips = true
+	out = wiki.Dot(0, dot.Directed).String()
+	if !strings.Contains(out, `tooltip="0 outgoing, 1 incoming links; 2 words: A note"`) {
+		t.Errorf("Expected tooltip with links, words and headings in output:\n%v", out)
+	}
+}
+
+func TestIgnoreRelativePaths(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":         "[[archive/old]] [[a/b]] [[/archive/other]]",
+		"archive/old.wiki":   "[[../index]]",
+		"archive/other.wiki": "",
+		"a/b.wiki":           "[[../archive/old]] [[archive/x]]",
+		"a/archive/x.wiki":   "[[../b]]",
+	})
+	defer clean()
+
+	// the anchored pattern matches both the files and the links to them
+	wiki, err := NewWiki(root, make(map[string]string), false, "^archive/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "[[a/archive/x.wiki a/b.wiki] [a/b.wiki a/archive/x.wiki] [index.wiki a/b.wiki]]"
+	if got := fmt.Sprint(wiki.Edges()); got != exp {
+		t.Errorf("Expected edges %v, got %v", exp, got)
+	}
+}
+
+func TestRemapLabels(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":      "[[projects/a]] [[projects/b]] [[diary/day]]",
+		"projects/a.wiki": "[[b]] [[../index]]",
+		"projects/b.wiki": "",
+		"diary/day.wiki":  "[[../index]]",
+	})
+	defer clean()
+
+	remap := map[string]string{"projects": "projects.wiki|Projects", "diary": "diary.wiki"}
+	wiki, err := NewWiki(root, remap, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the label does not affect the identity of the node
+	exp := "[[diary.wiki index.wiki] [index.wiki diary.wiki] [index.wiki projects.wiki] [projects.wiki index.wiki] [projects.wiki projects.wiki]]"
+	if got := fmt.Sprint(wiki.Edges()); got != exp {
+		t.Errorf("Expected edges %v, got %v", exp, got)
+	}
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{`[label="Projects"]`, `[label="diary.wiki"]`} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+	if got := wiki.label("projects.wiki"); got != "Projects" {
+		t.Errorf("Expected label Projects, got %v", got)
+	}
+
+	// aliases still take precedence
+	wiki.Aliases = map[string]string{"projects.wiki": "Work"}
+	if out := wiki.Dot(0, dot.Directed).String(); !strings.Contains(out, `[label="Work"]`) {
+		t.Errorf("Expected alias to override the label of the remap:\n%v", out)
+	}
+}
+
+func TestUnicodeNames(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki":      "[[📝 ideas]] [[🧑‍💻 work|my 🧑‍💻 work]] [[a \"quoted\" name]] [[📁 dir/note]]",
+		"📁 dir/note.wiki": "[[../index]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+	wiki.EdgeLabels = true
+
+	out := wiki.Dot(0, dot.Directed).String()
+	for _, exp := range []string{
+		`[label="📝 ideas.wiki"]`,
+		`[label="🧑‍💻 work.wiki"]`,
+		`[label="a \"quoted\" name.wiki"]`,
+		`[label="📁 dir/note.wiki"]`,
+		`label="📁 dir/";`,
+		`[label="my 🧑‍💻 work"]`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("Expected %v in output:\n%v", exp, out)
+		}
+	}
+
+	// GraphViz does not read Go escapes, e.g. of the zero width joiner
+	if strings.Contains(out, `\u`) {
+		t.Errorf("Expected no escaped characters in output:\n%v", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if n := strings.Count(line, `"`) - strings.Count(line, `\"`); n%2 != 0 {
+			t.Errorf("Expected only terminated strings, got %v", line)
+		}
+	}
+}
+
+func TestMinWeight(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.wiki": "[[a]] [[a]] [[a]] [[b]]",
+		"a.wiki":     "[[b]] [[b]]",
+		"b.wiki":     "[[c]]",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+	wiki.MinWeight = 2
+
+	// b.wiki keeps its strong incoming link, while c.wiki is left without
+	out := wiki.Dot(1, dot.Directed).String()
+	if got := strings.Count(out, "->"); got != 2 {
+		t.Errorf("Expected two edges of at least two links, got %v:\n%v", got, out)
+	}
+	if strings.Contains(out, `"c.wiki"`) {
+		t.Errorf("Expected node without strong edges to be left out:\n%v", out)
+	}
+
+	// index.wiki has a single strong link, which is below level 2
+	out = wiki.Dot(2, dot.Directed).String()
+	if strings.Contains(out, `label="index.wiki"`) || strings.Count(out, "->") != 1 {
+		t.Errorf("Expected only the edge from a.wiki above the level:\n%v", out)
+	}
+
+	// the graph itself is not filtered
+	if got := len(wiki.Edges()); got != 4 {
+		t.Errorf("Expected all four edges to remain in the graph, got %v", got)
+	}
+}
+
+func TestWalkDefaultExt(t *testing.T) {
+	root, clean := writeWiki(t, map[string]string{
+		"index.md": "[[foo]] [bar](bar)",
+		"foo.md":   "[[index]]",
+		"bar.md":   "",
+	})
+	defer clean()
+
+	wiki, err := NewWiki(root, make(map[string]string), false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wiki.DefaultExt = ".md"
+	if err := wiki.Walk(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "[[foo.md index.md] [index.md bar.md] [index.md foo.md]]"
+	if got := fmt.Sprint(wiki.Edges()); got != exp {
+		t.Errorf("Expected bare links to refer to markdown files, got %v", got)
+	}
+}